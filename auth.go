@@ -0,0 +1,333 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// Authenticator adds credentials to the requests sent by a [Client].
+//
+// Authenticate is called before every attempt of a request (including any
+// retries) with a copy of the request, so credentials are always current and
+// never leak into the caller's [Request].
+type Authenticator interface {
+	// Authenticate adds credentials to req.
+	Authenticate(ctx context.Context, req *Request) error
+}
+
+// ChallengeHandler may be implemented by an [Authenticator] to handle
+// "401 Unauthorized" responses.
+//
+// HandleChallenge is called with the authenticated request and its response.
+// If it returns true, the response is discarded and the request is
+// immediately authenticated and sent again, this happens at most once for
+// each attempt.
+type ChallengeHandler interface {
+	// HandleChallenge handles a "401 Unauthorized" response to req, returning
+	// whether the request should be sent again.
+	HandleChallenge(ctx context.Context, req *Request, res *Response) (bool, error)
+}
+
+// AuthenticatorFunc type is an adapter to allow the use of ordinary functions
+// as an [Authenticator]. If f is a function with the appropriate signature,
+// `AuthenticatorFunc(f)` is an [Authenticator] that calls f.
+type AuthenticatorFunc func(ctx context.Context, req *Request) error
+
+// Ensure that [AuthenticatorFunc] implements the [Authenticator] interface.
+var _ Authenticator = (*AuthenticatorFunc)(nil)
+
+// Authenticate calls f(ctx, req).
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, req *Request) error {
+	return f(ctx, req)
+}
+
+// BearerToken returns an [Authenticator] that sets the "Authorization" header
+// of requests to a static bearer token.
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(_ context.Context, req *Request) error {
+		req.SetHeader(httpheader.Authorization, "Bearer "+token)
+		return nil
+	})
+}
+
+// BasicAuth returns an [Authenticator] that uses HTTP Basic Authentication
+// with the given username and password.
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(_ context.Context, req *Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// tokenRefreshRatio is the ratio of the lifetime of a token after which it is
+// refreshed in the background.
+const tokenRefreshRatio = 0.8
+
+// tokenExpiryDelta is how long before a [Token] expires that it is considered
+// expired, to avoid using a token that expires while a request is in flight.
+const tokenExpiryDelta = 10 * time.Second
+
+// Token is an access token used to authenticate requests.
+type Token struct {
+	// AccessToken used to authenticate requests.
+	AccessToken string
+	// TokenType of the token, such as "Bearer". If empty, "Bearer" is used.
+	TokenType string
+	// Expiry of the token, if zero the token never expires.
+	Expiry time.Time
+}
+
+// Valid reports whether the token is set and not (about to be) expired.
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Until(t.Expiry) > tokenExpiryDelta
+}
+
+// authorization returns the value of the "Authorization" header for the
+// token.
+func (t *Token) authorization() string {
+	typ := t.TokenType
+	if typ == "" {
+		typ = "Bearer"
+	}
+	return typ + " " + t.AccessToken
+}
+
+// TokenSource returns tokens used to authenticate requests.
+type TokenSource interface {
+	// Token returns a new token.
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc type is an adapter to allow the use of ordinary functions as
+// a [TokenSource]. If f is a function with the appropriate signature,
+// `TokenSourceFunc(f)` is a [TokenSource] that calls f.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Ensure that [TokenSourceFunc] implements the [TokenSource] interface.
+var _ TokenSource = (*TokenSourceFunc)(nil)
+
+// Token calls f(ctx).
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// TokenAuthenticator is an [Authenticator] that authenticates requests using
+// tokens from a [TokenSource].
+//
+// Tokens are cached until shortly before they expire. Once 80% of the lifetime
+// of a token has passed, a new token is requested in the background while the
+// current token continues to be used. If the server responds with a
+// "401 Unauthorized" response containing a "WWW-Authenticate" header, the
+// token is refreshed and the request is sent again. Refreshes are shared
+// between all concurrent requests, so the [TokenSource] is only called once
+// no matter how many requests need a new token.
+type TokenAuthenticator struct {
+	source TokenSource
+
+	mu    sync.Mutex
+	token *Token
+	// refreshAt is when the token should be refreshed in the background, if
+	// zero the token is never refreshed before it expires.
+	refreshAt time.Time
+	// refreshing is set while a refresh is in progress.
+	refreshing *tokenRefresh
+}
+
+// tokenRefresh is a refresh of a token shared between callers.
+type tokenRefresh struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+var (
+	_ Authenticator    = (*TokenAuthenticator)(nil)
+	_ ChallengeHandler = (*TokenAuthenticator)(nil)
+	_ TokenSource      = (*TokenAuthenticator)(nil)
+)
+
+// NewTokenAuthenticator returns a new [TokenAuthenticator] using tokens from
+// source.
+func NewTokenAuthenticator(source TokenSource) *TokenAuthenticator {
+	return &TokenAuthenticator{source: source}
+}
+
+// Token returns the current token, refreshing it if it is not valid.
+func (a *TokenAuthenticator) Token(ctx context.Context) (*Token, error) {
+	a.mu.Lock()
+	tok := a.token
+	if tok.Valid() {
+		// If the token is close to expiring, refresh it in the background so
+		// requests don't have to wait for a new token once it does.
+		if !a.refreshAt.IsZero() && time.Now().After(a.refreshAt) && a.refreshing == nil {
+			a.startRefresh(ctx)
+		}
+		a.mu.Unlock()
+		return tok, nil
+	}
+	a.mu.Unlock()
+	return a.refresh(ctx, tok)
+}
+
+// Authenticate sets the "Authorization" header of req using the current
+// token.
+func (a *TokenAuthenticator) Authenticate(ctx context.Context, req *Request) error {
+	tok, err := a.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.SetHeader(httpheader.Authorization, tok.authorization())
+	return nil
+}
+
+// HandleChallenge refreshes the token used by req if the server rejected it.
+func (a *TokenAuthenticator) HandleChallenge(ctx context.Context, req *Request, res *Response) (bool, error) {
+	if res.GetHeader(httpheader.WWWAuthenticate) == "" {
+		return false, nil
+	}
+
+	// Only refresh the token if it is the one the request was sent with,
+	// otherwise another request already refreshed it.
+	a.mu.Lock()
+	tok := a.token
+	a.mu.Unlock()
+	if tok != nil && tok.authorization() != httpheader.Get(req.Header, httpheader.Authorization) {
+		return true, nil
+	}
+	if _, err := a.refresh(ctx, tok); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// refresh replaces the stale token with a new one from the [TokenSource]. If
+// a refresh is already in progress, its result is used instead.
+func (a *TokenAuthenticator) refresh(ctx context.Context, stale *Token) (*Token, error) {
+	a.mu.Lock()
+	if a.token != stale && a.token.Valid() {
+		tok := a.token
+		a.mu.Unlock()
+		return tok, nil
+	}
+	r := a.refreshing
+	if r == nil {
+		r = a.startRefresh(ctx)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.token, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startRefresh starts refreshing the token, a.mu must be held.
+func (a *TokenAuthenticator) startRefresh(ctx context.Context) *tokenRefresh {
+	r := &tokenRefresh{done: make(chan struct{})}
+	a.refreshing = r
+
+	// The refresh is shared, so it must not be cancelled by the context of
+	// whichever request happened to start it.
+	go a.doRefresh(context.WithoutCancel(ctx), r)
+	return r
+}
+
+// doRefresh gets a new token from the [TokenSource], completing r.
+func (a *TokenAuthenticator) doRefresh(ctx context.Context, r *tokenRefresh) {
+	tok, err := a.source.Token(ctx)
+	if err == nil && !tok.Valid() {
+		err = errors.New("nxhttp: token source returned an invalid token")
+	}
+	if err != nil {
+		tok, err = nil, fmt.Errorf("nxhttp: failed to refresh token: %w", err)
+	}
+
+	a.mu.Lock()
+	now := time.Now()
+	switch {
+	case err == nil:
+		a.token = tok
+		a.refreshAt = time.Time{}
+		if !tok.Expiry.IsZero() {
+			a.refreshAt = now.Add(time.Duration(float64(tok.Expiry.Sub(now)) * tokenRefreshRatio))
+		}
+	case a.token.Valid():
+		// Avoid retrying a failed background refresh on every request, the
+		// current token can still be used for now.
+		a.refreshAt = now.Add(tokenExpiryDelta)
+	}
+	a.refreshing = nil
+	a.mu.Unlock()
+
+	r.token, r.err = tok, err
+	close(r.done)
+}
+
+// send sends req after authenticating and signing it according to o, sending
+// it again if the [Authenticator] handles the challenge of a
+// "401 Unauthorized" response.
+func send(ctx context.Context, c *http.Client, req *Request, o *requestOptions) (*Response, error) {
+	if o.authenticator == nil && o.signer == nil {
+		return doRequest(c, o.withUploadProgress(req))
+	}
+
+	areq, err := o.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := doRequest(c, o.withUploadProgress(areq))
+	if err != nil || r == nil || r.StatusCode != http.StatusUnauthorized {
+		return r, err
+	}
+
+	h, ok := o.authenticator.(ChallengeHandler)
+	if !ok {
+		return r, nil
+	}
+	retry, err := h.HandleChallenge(ctx, areq, r)
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	if !retry {
+		return r, nil
+	}
+	_ = r.Close()
+
+	// The server rejected the request before processing it, so sending it
+	// again is safe regardless of the method.
+	if areq, err = o.prepare(ctx, req); err != nil {
+		return nil, err
+	}
+	return doRequest(c, o.withUploadProgress(areq))
+}
+
+// prepare returns a copy of req that is authenticated and signed.
+func (o *requestOptions) prepare(ctx context.Context, req *Request) (*Request, error) {
+	req = req.clone()
+	if o.authenticator != nil {
+		if err := o.authenticator.Authenticate(ctx, req); err != nil {
+			return nil, fmt.Errorf("nxhttp: failed to authenticate request: %w", err)
+		}
+	}
+	if o.signer != nil {
+		if err := o.signer.SignRequest(ctx, req); err != nil {
+			return nil, fmt.Errorf("nxhttp: failed to sign request: %w", err)
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+func TestAuthenticator(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithAuthenticator(nxhttp.BearerToken("token")))
+	for i, tc := range []struct {
+		opts     []nxhttp.RequestOption
+		expected string
+	}{
+		{nil, "Bearer token"},
+		{[]nxhttp.RequestOption{nxhttp.WithRequestAuthenticator(nxhttp.BasicAuth("user", "pass"))}, "Basic dXNlcjpwYXNz"},
+		{[]nxhttp.RequestOption{nxhttp.WithRequestAuthenticator(nil)}, ""},
+	} {
+		req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Do(req, tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(res.Body)
+		_ = res.Close()
+		if string(b) != tc.expected {
+			t.Errorf("#%d: expected Authorization to be '%s', but got '%s'", i, tc.expected, b)
+		}
+		if req.Header.Get("Authorization") != "" {
+			t.Errorf("#%d: expected the caller's request to not be modified", i)
+		}
+	}
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	// The server only accepts the second token, and the body of the request
+	// must be sent with every attempt.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	defer ts.Close()
+
+	var n atomic.Int32
+	auth := nxhttp.NewTokenAuthenticator(nxhttp.TokenSourceFunc(func(context.Context) (*nxhttp.Token, error) {
+		return &nxhttp.Token{AccessToken: "token-" + strconv.Itoa(int(n.Add(1)))}, nil
+	}))
+	if _, err := auth.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every request is rejected with the first token, but the token must only
+	// be refreshed once.
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithAuthenticator(auth))
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			body := "request " + strconv.Itoa(i)
+			req, err := nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL, strings.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res, err := c.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer res.Close()
+			b, _ := io.ReadAll(res.Body)
+			if res.StatusCode != http.StatusOK || string(b) != body {
+				t.Errorf("expected a 200 response with '%s', but got %d with '%s'", body, res.StatusCode, b)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := n.Load(); got != 2 {
+		t.Errorf("expected the token to be fetched 2 times, but got %d", got)
+	}
+}
@@ -77,6 +77,12 @@ func GetBody(v any) (BodyFunc, int64, error) {
 		return bodyFuncFromFile(body, s.Size())
 	case io.ReadSeeker:
 		return bodyFuncFromReadSeeker(body), getLen(body), nil
+	case JSONBody:
+		b, err := body.marshal()
+		if err != nil {
+			return nil, 0, err
+		}
+		return bodyFuncFromReadSeekerSize(bytes.NewReader(b))
 	case []byte:
 		return bodyFuncFromReadSeekerSize(bytes.NewReader(body))
 	case string:
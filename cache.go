@@ -0,0 +1,520 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// maxCacheEntrySize is the maximum size of a response body that will be
+// stored in the cache.
+const maxCacheEntrySize = 8 * 1024 * 1024
+
+// cacheableStatusCodes are the status codes of responses that may be stored
+// in the cache.
+//
+// See https://www.rfc-editor.org/rfc/rfc9110#section-15.1
+var cacheableStatusCodes = []int{
+	http.StatusOK,
+	http.StatusNonAuthoritativeInfo,
+	http.StatusNoContent,
+	http.StatusMultipleChoices,
+	http.StatusMovedPermanently,
+	http.StatusPermanentRedirect,
+	http.StatusNotFound,
+	http.StatusMethodNotAllowed,
+	http.StatusGone,
+	http.StatusRequestURITooLong,
+	http.StatusNotImplemented,
+}
+
+// cache is a private HTTP cache (RFC 9111) used by a [Client].
+type cache struct {
+	store CacheStore
+
+	// revalidating is the set of keys currently being revalidated in the
+	// background.
+	revalidating sync.Map
+}
+
+// cacheEntry is a response stored in the cache.
+type cacheEntry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	// Vary contains the values of the request headers that were nominated by
+	// the "Vary" header of the response.
+	Vary http.Header `json:"vary,omitempty"`
+	// Uncompressed indicates the body was decompressed before it was stored.
+	Uncompressed bool `json:"uncompressed,omitempty"`
+	// RequestTime is the time the request that received the response was
+	// sent.
+	RequestTime time.Time `json:"request_time"`
+	// ResponseTime is the time the response was received.
+	ResponseTime time.Time `json:"response_time"`
+}
+
+// cacheKey returns the key used to store the response for req.
+func cacheKey(req *Request) string {
+	return http.MethodGet + " " + req.URL.String()
+}
+
+// do sends req using the cache, only sending it to the server if there is no
+// stored response that can be used.
+func (h *cache) do(c *Client, req *Request, opts []RequestOption) (*Response, error) {
+	ctx := req.Context()
+
+	// Only GET requests are cached, unsafe methods invalidate any stored
+	// response for the same URL if they succeed.
+	if req.Method != http.MethodGet {
+		res, err := c.do(req, opts)
+		if err == nil && res != nil && !isSafe(req.Method) && res.StatusCode < http.StatusBadRequest {
+			_ = h.store.Delete(ctx, cacheKey(req))
+		}
+		return res, err
+	}
+
+	reqCC := httpheader.ParseCacheControl(httpheader.Values(req.Header, httpheader.CacheControl)...)
+	if reqCC.NoStore || httpheader.Get(req.Header, httpheader.Range) != "" {
+		return c.do(req, opts)
+	}
+
+	key := cacheKey(req)
+	entry := h.get(ctx, key, req)
+	if entry == nil {
+		if reqCC.OnlyIfCached {
+			return c.cached(req, gatewayTimeout(req), opts, false)
+		}
+		return h.fetch(c, key, req, opts)
+	}
+
+	age, lifetime := entry.age(time.Now()), entry.lifetime()
+	resCC := entry.cacheControl()
+	if entry.isFresh(reqCC, resCC, age, lifetime) {
+		return c.cached(req, entry.response(req, age), opts, false)
+	}
+	if reqCC.OnlyIfCached {
+		return c.cached(req, gatewayTimeout(req), opts, false)
+	}
+
+	// If allowed, serve the stale response while revalidating it in the
+	// background.
+	if !resCC.MustRevalidate && !resCC.NoCache && !reqCC.NoCache && resCC.StaleWhileRevalidate >= 0 && age < addDuration(lifetime, resCC.StaleWhileRevalidate) {
+		res := entry.response(req, age)
+		h.revalidateInBackground(c, key, req, entry, opts)
+		return c.cached(req, res, opts, false)
+	}
+
+	res, err := h.revalidate(c, key, req, entry, opts)
+	if err != nil || res == nil || !res.FromCache() {
+		return res, err
+	}
+	return c.cached(req, res, opts, true)
+}
+
+// cached applies the options of the request to res, a response served from
+// the cache, as if it had been received from the server. If sent is false, the
+// request was never sent to the server, in which case the response is reported
+// as the only attempt of the request.
+func (c *Client) cached(req *Request, res *Response, opts []RequestOption, sent bool) (*Response, error) {
+	reqOpts := c.requestOptions()
+	for _, opt := range opts {
+		opt.apply(reqOpts)
+	}
+	if !sent && c.onAttempt != nil {
+		c.onAttempt(req.Context(), Attempt{Request: req, Number: 1, StatusCode: res.StatusCode})
+	}
+	if err := c.wrapResponse(res, reqOpts, false); err != nil {
+		_ = res.Close()
+		return nil, err
+	}
+	if reqOpts.isUnexpected(res.StatusCode) {
+		return nil, NewStatusError(res, reqOpts.expectStatus...)
+	}
+	return res, nil
+}
+
+// get returns the stored response for key if it can be used for req.
+func (h *cache) get(ctx context.Context, key string, req *Request) *cacheEntry {
+	b, ok, err := h.store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil
+	}
+	// Only one variant is stored for each key, so if the request doesn't
+	// match the variant that was stored, it is a miss.
+	for name, values := range entry.Vary {
+		if !slices.Equal(req.Header.Values(name), values) {
+			return nil
+		}
+	}
+	return &entry
+}
+
+// set stores entry for key.
+func (h *cache) set(ctx context.Context, key string, entry *cacheEntry) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_ = h.store.Set(ctx, key, b)
+}
+
+// fetch sends req to the server, storing the response once its body has been
+// read if it can be cached.
+func (h *cache) fetch(c *Client, key string, req *Request, opts []RequestOption) (*Response, error) {
+	requestTime := time.Now()
+	res, err := c.do(req, opts)
+	if err != nil || res == nil {
+		return res, err
+	}
+	h.storeResponse(key, req, res, requestTime)
+	return res, nil
+}
+
+// revalidate sends a conditional request for the stored entry, returning the
+// stored response if the server indicates it is unchanged.
+//
+// The entry is never modified, if the server indicates it is unchanged an
+// updated copy of it is stored instead.
+func (h *cache) revalidate(c *Client, key string, req *Request, entry *cacheEntry, opts []RequestOption) (*Response, error) {
+	ctx := req.Context()
+	creq := req.clone()
+	if etag := httpheader.Get(entry.Header, httpheader.ETag); etag != "" {
+		creq.SetHeader(httpheader.IfNoneMatch, etag)
+	}
+	if lm := httpheader.Get(entry.Header, httpheader.LastModified); lm != "" {
+		creq.SetHeader(httpheader.IfModifiedSince, lm)
+	}
+
+	requestTime := time.Now()
+	res, err := c.do(creq, append(slices.Clip(opts), allowNotModified()))
+
+	// If the server is unavailable, the stale response may be used instead.
+	if err != nil || res == nil || isServerError(res.StatusCode) {
+		if age := entry.age(time.Now()); entry.staleIfError(req, age) {
+			if res != nil {
+				_ = res.Close()
+			}
+			return entry.response(req, age), nil
+		}
+		return res, err
+	}
+
+	if res.StatusCode != http.StatusNotModified {
+		h.storeResponse(key, req, res, requestTime)
+		return res, nil
+	}
+
+	// The stored response is still valid, update it using the headers of the
+	// 304 response.
+	_ = res.Close()
+	updated := entry.clone()
+	for name, values := range res.Header {
+		switch httpheader.Key(name) {
+		case httpheader.ContentLength, httpheader.ContentEncoding, httpheader.TransferEncoding:
+			continue
+		}
+		updated.Header[name] = values
+	}
+	updated.RequestTime, updated.ResponseTime = requestTime, time.Now()
+	h.set(ctx, key, updated)
+	return updated.response(req, updated.age(time.Now())), nil
+}
+
+// revalidateInBackground revalidates the stored entry without blocking the
+// caller. Only one revalidation happens at a time for each key.
+func (h *cache) revalidateInBackground(c *Client, key string, req *Request, entry *cacheEntry, opts []RequestOption) {
+	if _, loaded := h.revalidating.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	req = req.WithContext(context.WithoutCancel(req.Context()))
+	go func() {
+		defer h.revalidating.Delete(key)
+		res, err := h.revalidate(c, key, req, entry, opts)
+		if err != nil || res == nil {
+			return
+		}
+		// Read the body to completion so the response gets stored.
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Close()
+	}()
+}
+
+// storeResponse wraps the body of res so the response is stored once the
+// body has been read to completion, if the response can be cached.
+func (h *cache) storeResponse(key string, req *Request, res *Response, requestTime time.Time) {
+	responseTime := time.Now()
+	if !isStorable(res) {
+		return
+	}
+
+	entry := &cacheEntry{
+		StatusCode:   res.StatusCode,
+		Header:       res.Header.Clone(),
+		Uncompressed: res.Uncompressed,
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+	}
+	for _, v := range httpheader.Values(res.Header, httpheader.Vary) {
+		for name := range strings.SplitSeq(v, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if entry.Vary == nil {
+				entry.Vary = make(http.Header)
+			}
+			name = string(httpheader.Canonicalize(name))
+			entry.Vary[name] = req.Header.Values(name)
+		}
+	}
+
+	ctx := context.WithoutCancel(req.Context())
+	if res.Body == nil {
+		h.set(ctx, key, entry)
+		return
+	}
+	res.Body = &cachingReadCloser{
+		ReadCloser: res.Body,
+		done: func(body []byte) {
+			entry.Body = body
+			h.set(ctx, key, entry)
+		},
+	}
+}
+
+// isStorable reports whether res may be stored in the cache.
+func isStorable(res *Response) bool {
+	if !slices.Contains(cacheableStatusCodes, res.StatusCode) {
+		return false
+	}
+	if res.ContentLength > maxCacheEntrySize {
+		return false
+	}
+	cc := httpheader.ParseCacheControl(httpheader.Values(res.Header, httpheader.CacheControl)...)
+	if cc.NoStore {
+		return false
+	}
+	for _, v := range httpheader.Values(res.Header, httpheader.Vary) {
+		if strings.TrimSpace(v) == "*" {
+			return false
+		}
+	}
+
+	// Without any freshness information or validators, the stored response
+	// could never be used.
+	return cc.MaxAge >= 0 ||
+		httpheader.Get(res.Header, httpheader.Expires) != "" ||
+		httpheader.Get(res.Header, httpheader.ETag) != "" ||
+		httpheader.Get(res.Header, httpheader.LastModified) != ""
+}
+
+// clone returns a copy of the entry that can be modified without affecting
+// the original. The body is shared, as it is never modified.
+func (e *cacheEntry) clone() *cacheEntry {
+	c := *e
+	c.Header = e.Header.Clone()
+	c.Vary = e.Vary.Clone()
+	return &c
+}
+
+// cacheControl returns the "Cache-Control" directives of the stored response.
+func (e *cacheEntry) cacheControl() httpheader.CacheDirectives {
+	return httpheader.ParseCacheControl(httpheader.Values(e.Header, httpheader.CacheControl)...)
+}
+
+// age returns the current age of the stored response.
+//
+// See https://www.rfc-editor.org/rfc/rfc9111#section-4.2.3
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	var apparentAge time.Duration
+	if date, err := http.ParseTime(httpheader.Get(e.Header, httpheader.Date)); err == nil {
+		apparentAge = max(0, e.ResponseTime.Sub(date))
+	}
+	var ageValue time.Duration
+	if v, err := strconv.ParseInt(httpheader.Get(e.Header, httpheader.Age), 10, 64); err == nil && v > 0 {
+		ageValue = time.Duration(v) * time.Second
+	}
+	correctedAgeValue := ageValue + e.ResponseTime.Sub(e.RequestTime)
+	return max(apparentAge, correctedAgeValue) + now.Sub(e.ResponseTime)
+}
+
+// lifetime returns the freshness lifetime of the stored response.
+//
+// See https://www.rfc-editor.org/rfc/rfc9111#section-4.2.1
+func (e *cacheEntry) lifetime() time.Duration {
+	if cc := e.cacheControl(); cc.MaxAge >= 0 {
+		return cc.MaxAge
+	}
+
+	date, err := http.ParseTime(httpheader.Get(e.Header, httpheader.Date))
+	if err != nil {
+		date = e.ResponseTime
+	}
+	if v := httpheader.Get(e.Header, httpheader.Expires); v != "" {
+		// An invalid Expires header represents a time in the past.
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		return max(0, expires.Sub(date))
+	}
+
+	// Use a heuristic freshness lifetime of 10% of the time since the
+	// resource was last modified.
+	if lm, err := http.ParseTime(httpheader.Get(e.Header, httpheader.LastModified)); err == nil {
+		return max(0, date.Sub(lm)/10)
+	}
+	return 0
+}
+
+// isFresh reports whether the stored response can be used for a request
+// without revalidating it.
+func (e *cacheEntry) isFresh(reqCC, resCC httpheader.CacheDirectives, age, lifetime time.Duration) bool {
+	if reqCC.NoCache || resCC.NoCache {
+		return false
+	}
+	if reqCC.MaxAge >= 0 && age > reqCC.MaxAge {
+		return false
+	}
+	if reqCC.MinFresh >= 0 && lifetime-age < reqCC.MinFresh {
+		return false
+	}
+	if age < lifetime {
+		return true
+	}
+	// The client is willing to accept a stale response.
+	return reqCC.MaxStale >= 0 && !resCC.MustRevalidate && age-lifetime < reqCC.MaxStale
+}
+
+// staleIfError reports whether the stored response can be used for req when
+// the server is unavailable.
+func (e *cacheEntry) staleIfError(req *Request, age time.Duration) bool {
+	resCC := e.cacheControl()
+	if resCC.MustRevalidate || resCC.NoCache {
+		return false
+	}
+	reqCC := httpheader.ParseCacheControl(httpheader.Values(req.Header, httpheader.CacheControl)...)
+	d := max(resCC.StaleIfError, reqCC.StaleIfError)
+	return d >= 0 && age < addDuration(e.lifetime(), d)
+}
+
+// addDuration returns a+b for non-negative durations, saturating at the
+// maximum [time.Duration] instead of overflowing, as the directives of a
+// response may be large enough to overflow when combined.
+func addDuration(a, b time.Duration) time.Duration {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// response returns a new [Response] for req from the stored response.
+func (e *cacheEntry) response(req *Request, age time.Duration) *Response {
+	header := e.Header.Clone()
+	httpheader.Set(header, httpheader.Age, strconv.FormatInt(int64(age/time.Second), 10))
+	return &Response{
+		Response: &http.Response{
+			Status:        strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode),
+			StatusCode:    e.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(e.Body)),
+			ContentLength: int64(len(e.Body)),
+			Request:       req.Request,
+			Uncompressed:  e.Uncompressed,
+		},
+		fromCache: true,
+	}
+}
+
+// gatewayTimeout returns a "504 Gateway Timeout" response, used when the
+// request only allows a cached response but none is stored.
+func gatewayTimeout(req *Request) *Response {
+	return &Response{
+		Response: &http.Response{
+			Status:     strconv.Itoa(http.StatusGatewayTimeout) + " " + http.StatusText(http.StatusGatewayTimeout),
+			StatusCode: http.StatusGatewayTimeout,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    req.Request,
+		},
+		fromCache: true,
+	}
+}
+
+// isSafe reports whether method is a safe HTTP method.
+func isSafe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// isServerError reports whether a response with the given status code
+// indicates the server is unavailable, allowing a stale response to be used.
+func isServerError(code int) bool {
+	switch code {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// cachingReadCloser is an [io.ReadCloser] that buffers the data read from it,
+// calling done with the data once it has been read to completion.
+//
+// If the body is larger than [maxCacheEntrySize] or reading it fails, done is
+// never called.
+type cachingReadCloser struct {
+	io.ReadCloser
+
+	buf     bytes.Buffer
+	done    func([]byte)
+	skipped bool
+}
+
+// Read reads from the underlying [io.ReadCloser], buffering any data read.
+func (r *cachingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if r.skipped {
+		return n, err
+	}
+	if r.buf.Len()+n > maxCacheEntrySize {
+		r.skipped, r.buf = true, bytes.Buffer{}
+		return n, err
+	}
+	r.buf.Write(p[:n])
+	switch {
+	case err == io.EOF:
+		r.skipped = true
+		r.done(r.buf.Bytes())
+	case err != nil:
+		r.skipped, r.buf = true, bytes.Buffer{}
+	}
+	return n, err
+}
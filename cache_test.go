@@ -0,0 +1,294 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// get sends a GET request for url, returning the response with its body
+// read to completion.
+func get(t *testing.T, c *nxhttp.Client, url string, header http.Header) (*nxhttp.Response, string) {
+	t.Helper()
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, string(b)
+}
+
+func TestWithCache(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// handler for the n-th request to the server, starting at 1.
+		handler func(w http.ResponseWriter, r *http.Request, n int32)
+		header  http.Header
+		// requests is the number of requests the server should receive.
+		requests int32
+		// fromCache indicates the second response should be from the cache.
+		fromCache bool
+	}{
+		{
+			name: "fresh",
+			handler: func(w http.ResponseWriter, _ *http.Request, n int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				_, _ = io.WriteString(w, "body")
+			},
+			requests:  1,
+			fromCache: true,
+		},
+		{
+			name: "no-store",
+			handler: func(w http.ResponseWriter, _ *http.Request, n int32) {
+				w.Header().Set("Cache-Control", "no-store, max-age=60")
+				_, _ = io.WriteString(w, "body")
+			},
+			requests: 2,
+		},
+		{
+			name: "revalidate",
+			handler: func(w http.ResponseWriter, r *http.Request, n int32) {
+				w.Header().Set("Cache-Control", "max-age=0")
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				_, _ = io.WriteString(w, "body")
+			},
+			requests:  2,
+			fromCache: true,
+		},
+		{
+			name: "vary",
+			handler: func(w http.ResponseWriter, r *http.Request, n int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("Vary", "Accept-Language")
+				_, _ = io.WriteString(w, "body")
+			},
+			header:   http.Header{"Accept-Language": {"fr"}},
+			requests: 2,
+		},
+		{
+			name: "stale-if-error",
+			handler: func(w http.ResponseWriter, _ *http.Request, n int32) {
+				if n > 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Cache-Control", "max-age=0, stale-if-error=60")
+				w.Header().Set("ETag", `"v1"`)
+				_, _ = io.WriteString(w, "body")
+			},
+			requests:  2,
+			fromCache: true,
+		},
+		{
+			name: "request no-cache",
+			handler: func(w http.ResponseWriter, _ *http.Request, n int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				_, _ = io.WriteString(w, "body")
+			},
+			header:   http.Header{"Cache-Control": {"no-cache"}},
+			requests: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var n atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tc.handler(w, r, n.Add(1))
+			}))
+			defer ts.Close()
+
+			c := nxhttp.FromClient(ts.Client(), nxhttp.WithCache(nxhttp.NewMemoryCache(0)), nxhttp.MaxAttempts(1))
+			if res, _ := get(t, c, ts.URL, nil); res.FromCache() {
+				t.Error("expected the first response to not be from the cache")
+			}
+			res, body := get(t, c, ts.URL, tc.header)
+			if body != "body" {
+				t.Errorf("expected body to be 'body', but got '%s'", body)
+			}
+			if res.FromCache() != tc.fromCache {
+				t.Errorf("expected FromCache to be %t, but got %t", tc.fromCache, res.FromCache())
+			}
+			if got := n.Load(); got != tc.requests {
+				t.Errorf("expected %d requests, but got %d", tc.requests, got)
+			}
+		})
+	}
+}
+
+func TestWithCache_StaleWhileRevalidate(t *testing.T) {
+	var n atomic.Int32
+	revalidated := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		if n.Add(1) == 2 {
+			defer close(revalidated)
+		}
+		_, _ = io.WriteString(w, "body")
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithCache(nxhttp.NewMemoryCache(0)))
+	_, _ = get(t, c, ts.URL, nil)
+	if res, _ := get(t, c, ts.URL, nil); !res.FromCache() {
+		t.Error("expected the stale response to be served from the cache")
+	}
+	select {
+	case <-revalidated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the response to be revalidated in the background")
+	}
+}
+
+func TestWithCache_LargeDirectives(t *testing.T) {
+	// The second value overflows when parsed, and both must not overflow
+	// when added to the freshness lifetime of the response.
+	for _, v := range []string{"2147483648", "99999999999999999999"} {
+		for _, directive := range []string{"stale-while-revalidate", "stale-if-error"} {
+			t.Run(directive+"="+v, func(t *testing.T) {
+				var (
+					n           atomic.Int32
+					once        sync.Once
+					revalidated = make(chan struct{})
+				)
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					if n.Add(1) > 1 {
+						defer once.Do(func() { close(revalidated) })
+						w.WriteHeader(http.StatusInternalServerError)
+						return
+					}
+					w.Header().Set("Cache-Control", "max-age="+v+", "+directive+"="+v)
+					_, _ = io.WriteString(w, "body")
+				}))
+				defer ts.Close()
+
+				c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()), nxhttp.WithCache(nxhttp.NewMemoryCache(0)))
+				_, _ = get(t, c, ts.URL, nil)
+
+				// Requiring a fresh response makes the stored one stale.
+				res, body := get(t, c, ts.URL, http.Header{"Cache-Control": {"max-age=0"}})
+				if !res.FromCache() || body != "body" {
+					t.Errorf("expected the stale response to be served from the cache, but got a %d response", res.StatusCode)
+				}
+				select {
+				case <-revalidated:
+				case <-time.After(5 * time.Second):
+					t.Fatal("expected the response to be revalidated")
+				}
+			})
+		}
+	}
+}
+
+func TestWithCache_Invalidate(t *testing.T) {
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = io.WriteString(w, "body")
+	}))
+	defer ts.Close()
+
+	store, err := nxhttp.NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithCache(store))
+	_, _ = get(t, c, ts.URL, nil)
+	if res, _ := get(t, c, ts.URL, nil); !res.FromCache() {
+		t.Fatal("expected the response to be served from the cache")
+	}
+
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Close()
+
+	if res, _ := get(t, c, ts.URL, nil); res.FromCache() {
+		t.Error("expected the POST request to invalidate the cached response")
+	}
+	if got := n.Load(); got != 3 {
+		t.Errorf("expected 3 requests, but got %d", got)
+	}
+}
+
+func TestWithCache_ExpectStatus(t *testing.T) {
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithCache(nxhttp.NewMemoryCache(0)))
+	_, _ = get(t, c, ts.URL, nil)
+
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req, nxhttp.ExpectStatus(http.StatusOK))
+	if err == nil {
+		_ = res.Close()
+		t.Fatal("expected an error")
+	}
+	if sErr, ok := nxhttp.AsStatusError(err); !ok || sErr.StatusCode != http.StatusNotFound || string(sErr.Data) != "not found" {
+		t.Errorf("expected a StatusError for the cached response, but got %v", err)
+	}
+	if got := n.Load(); got != 1 {
+		t.Errorf("expected the response to be served from the cache, but got %d requests", got)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	c := nxhttp.NewMemoryCache(8)
+	_ = c.Set(ctx, "a", []byte("aaaa"))
+	_ = c.Set(ctx, "b", []byte("bbbb"))
+	_, _, _ = c.Get(ctx, "a")
+	_ = c.Set(ctx, "c", []byte("cccc"))
+
+	for key, ok := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, got, _ := c.Get(ctx, key); got != ok {
+			t.Errorf("expected '%s' to be cached: %t, but got %t", key, ok, got)
+		}
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	cc := httpheader.ParseCacheControl(`max-age="60", no-cache`, "stale-if-error=30, max-stale, max-age=abc")
+	if cc.MaxAge != time.Minute || cc.StaleIfError != 30*time.Second || !cc.NoCache || cc.MaxStale <= 0 || cc.StaleWhileRevalidate >= 0 {
+		t.Errorf("unexpected directives %+v", cc)
+	}
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheStore stores the responses cached by a [Client], see [WithCache].
+//
+// Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the value stored for key, and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value for key, replacing any existing value.
+	Set(ctx context.Context, key string, value []byte) error
+	// Delete removes the value stored for key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryCache is an in-memory [CacheStore] that evicts the least recently
+// used values once its maximum size is exceeded.
+type MemoryCache struct {
+	mu      sync.Mutex
+	maxSize int64
+	size    int64
+	lru     *list.List
+	items   map[string]*list.Element
+}
+
+// memoryCacheItem is a value stored in a [MemoryCache].
+type memoryCacheItem struct {
+	key   string
+	value []byte
+}
+
+var _ CacheStore = (*MemoryCache)(nil)
+
+// NewMemoryCache returns a new [MemoryCache] that stores up to maxSize bytes
+// of values. If maxSize is 0, the size of the cache is unlimited.
+func NewMemoryCache(maxSize int64) *MemoryCache {
+	return &MemoryCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value stored for key, and whether it was found.
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*memoryCacheItem).value, true, nil
+}
+
+// Set stores value for key, replacing any existing value. Values larger than
+// the maximum size of the cache are not stored.
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key)
+	if c.maxSize > 0 && int64(len(value)) > c.maxSize {
+		return nil
+	}
+
+	c.items[key] = c.lru.PushFront(&memoryCacheItem{key: key, value: value})
+	c.size += int64(len(value))
+	for c.maxSize > 0 && c.size > c.maxSize {
+		c.remove(c.lru.Back().Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+// Delete removes the value stored for key, if any.
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key)
+	return nil
+}
+
+// remove removes key from the cache, c.mu must be held.
+func (c *MemoryCache) remove(key string) {
+	e, ok := c.items[key]
+	if !ok {
+		return
+	}
+	c.lru.Remove(e)
+	delete(c.items, key)
+	c.size -= int64(len(e.Value.(*memoryCacheItem).value))
+}
+
+// DiskCache is a [CacheStore] that stores each value in a file within a
+// directory.
+//
+// DiskCache does not limit the size of the directory, it is up to the caller
+// to remove old files if necessary.
+type DiskCache struct {
+	dir string
+}
+
+var _ CacheStore = (*DiskCache)(nil)
+
+// NewDiskCache returns a new [DiskCache] storing values in dir, creating it if
+// it doesn't exist.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("nxhttp: failed to create cache directory: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the path of the file storing the value for key.
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get returns the value stored for key, and whether it was found.
+func (c *DiskCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// Set stores value for key, replacing any existing value.
+//
+// The value is written to a temporary file that replaces the existing file
+// once it is complete, so a concurrent Get never observes a partial value.
+func (c *DiskCache) Set(_ context.Context, key string, value []byte) error {
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(value); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), c.path(key)); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Delete removes the value stored for key, if any.
+func (c *DiskCache) Delete(_ context.Context, key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// DigestAlgorithm is a hash algorithm used for the "Content-Digest" and
+// "Repr-Digest" headers, as defined by RFC 9530.
+type DigestAlgorithm string
+
+const (
+	// DigestSHA256 is the "sha-256" digest algorithm.
+	DigestSHA256 DigestAlgorithm = "sha-256"
+	// DigestSHA512 is the "sha-512" digest algorithm.
+	DigestSHA512 DigestAlgorithm = "sha-512"
+)
+
+// digestAlgorithms are the supported digest algorithms, in order of
+// preference.
+var digestAlgorithms = []DigestAlgorithm{DigestSHA512, DigestSHA256}
+
+// digestAlgorithmNames returns the names of the supported digest algorithms.
+func digestAlgorithmNames() []string {
+	names := make([]string, len(digestAlgorithms))
+	for i, alg := range digestAlgorithms {
+		names[i] = string(alg)
+	}
+	return names
+}
+
+// newHash returns a new [hash.Hash] for the algorithm.
+func (a DigestAlgorithm) newHash() (hash.Hash, error) {
+	switch a {
+	case DigestSHA256:
+		return sha256.New(), nil
+	case DigestSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("nxhttp: unsupported digest algorithm '%s'", a)
+	}
+}
+
+// contentDigest returns the value of a "Content-Digest" header for the body
+// returned by fn using each of the given algorithms.
+func contentDigest(fn BodyFunc, algs []DigestAlgorithm) (string, error) {
+	hashes := make([]hash.Hash, len(algs))
+	writers := make([]io.Writer, len(algs))
+	for i, alg := range algs {
+		h, err := alg.newHash()
+		if err != nil {
+			return "", err
+		}
+		hashes[i], writers[i] = h, h
+	}
+
+	body, err := fn()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	if _, err := io.Copy(io.MultiWriter(writers...), body); err != nil {
+		return "", fmt.Errorf("nxhttp: failed to calculate digest of body: %w", err)
+	}
+
+	digests := make([]httpheader.Digest, len(algs))
+	for i, alg := range algs {
+		digests[i] = httpheader.Digest{Algorithm: string(alg), Value: hashes[i].Sum(nil)}
+	}
+	return httpheader.FormatDigest(digests...), nil
+}
+
+// digestCheck is a digest of a response body that is being verified.
+type digestCheck struct {
+	header    httpheader.Key
+	algorithm DigestAlgorithm
+	expected  []byte
+	hash      hash.Hash
+}
+
+// verifyDigest wraps the body of res so that once it has been read to
+// completion, it is verified against the "Content-Digest" and "Repr-Digest"
+// headers of the response.
+//
+// Only the strongest supported algorithm of each header is verified. If a
+// header is missing, or has no supported algorithms, it is ignored. A header
+// without any valid digests results in a [ContentError].
+func verifyDigest(res *Response) error {
+	var checks []digestCheck
+	for _, key := range []httpheader.Key{httpheader.ContentDigest, httpheader.ReprDigest} {
+		// The representation digest covers the entire representation, which
+		// a partial response doesn't contain.
+		if key == httpheader.ReprDigest && res.StatusCode == http.StatusPartialContent {
+			continue
+		}
+		v := res.GetHeader(key)
+		if v == "" {
+			continue
+		}
+		digests, err := httpheader.ParseDigest(v)
+		if err != nil {
+			return NewContentError(key, v, digestAlgorithmNames()...)
+		}
+		if c, ok := newDigestCheck(key, digests); ok {
+			checks = append(checks, c)
+		}
+	}
+	if len(checks) > 0 {
+		res.Body = &digestReadCloser{ReadCloser: res.Body, checks: checks}
+	}
+	return nil
+}
+
+// newDigestCheck returns a [digestCheck] for the strongest supported
+// algorithm in digests.
+func newDigestCheck(key httpheader.Key, digests []httpheader.Digest) (digestCheck, bool) {
+	for _, alg := range digestAlgorithms {
+		for _, d := range digests {
+			if d.Algorithm != string(alg) {
+				continue
+			}
+			h, _ := alg.newHash()
+			return digestCheck{header: key, algorithm: alg, expected: d.Value, hash: h}, true
+		}
+	}
+	return digestCheck{}, false
+}
+
+// digestReadCloser is an [io.ReadCloser] that hashes the data read from it,
+// returning a [DigestError] instead of [io.EOF] if the hashes don't match the
+// expected digests.
+type digestReadCloser struct {
+	io.ReadCloser
+
+	checks []digestCheck
+	err    error
+}
+
+// Read reads from the underlying [io.ReadCloser], verifying the digests once
+// the end of the body is reached.
+func (r *digestReadCloser) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.ReadCloser.Read(p)
+	for _, c := range r.checks {
+		c.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		for _, c := range r.checks {
+			if actual := c.hash.Sum(nil); !bytes.Equal(actual, c.expected) {
+				err = DigestError{
+					Header:    c.header,
+					Algorithm: c.algorithm,
+					Expected:  c.expected,
+					Actual:    actual,
+				}
+				break
+			}
+		}
+	}
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matthewpi/nxhttp"
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+func TestParseDigest(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello"))
+	v := httpheader.FormatDigest(
+		httpheader.Digest{Algorithm: "sha-256", Value: sum[:]},
+		httpheader.Digest{Algorithm: "unixsum", Value: []byte{1, 2}},
+	)
+	digests, err := httpheader.ParseDigest(v + ";param=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digests) != 2 || digests[0].Algorithm != "sha-256" || string(digests[0].Value) != string(sum[:]) {
+		t.Errorf("unexpected digests %v", digests)
+	}
+
+	// Members that aren't a valid digest, such as legacy algorithms using an
+	// Integer value, are skipped.
+	digests, err = httpheader.ParseDigest("unixsum=123, unixcksum=456;a=1, SHA-256=:aGVsbG8=:, " + v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digests) != 2 || digests[0].Algorithm != "sha-256" || string(digests[0].Value) != string(sum[:]) {
+		t.Errorf("unexpected digests %v", digests)
+	}
+
+	for _, v := range []string{"sha-256", "sha-256=abc", "SHA-256=:aGVsbG8=:", "sha-256=:!!:", "unixsum=123"} {
+		if _, err := httpheader.ParseDigest(v); err == nil {
+			t.Errorf("expected an error parsing '%s'", v)
+		}
+	}
+}
+
+func TestWithContentDigest(t *testing.T) {
+	body := "hello, world"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("Content-Digest"))
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client())
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req, nxhttp.WithContentDigest(nxhttp.DigestSHA256, nxhttp.DigestSHA512))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum256, sum512 := sha256.Sum256([]byte(body)), sha512.Sum512([]byte(body))
+	expected := httpheader.FormatDigest(
+		httpheader.Digest{Algorithm: "sha-256", Value: sum256[:]},
+		httpheader.Digest{Algorithm: "sha-512", Value: sum512[:]},
+	)
+	if string(b) != expected {
+		t.Errorf("expected Content-Digest to be '%s', but got '%s'", expected, b)
+	}
+}
+
+// bodyFor returns the body of a response with the given status code, which is
+// empty for status codes that never have a body.
+func bodyFor(status int, body string) string {
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		return ""
+	}
+	return body
+}
+
+func TestVerifyDigests(t *testing.T) {
+	body := "hello, world"
+	sum := sha256.Sum256([]byte(body))
+	valid := httpheader.FormatDigest(httpheader.Digest{Algorithm: "sha-256", Value: sum[:]})
+	invalid := httpheader.FormatDigest(httpheader.Digest{Algorithm: "sha-256", Value: make([]byte, sha256.Size)})
+
+	for i, tc := range []struct {
+		header httpheader.Key
+		value  string
+		status int
+		err    bool
+	}{
+		{httpheader.ContentDigest, valid, http.StatusOK, false},
+		{httpheader.ContentDigest, invalid, http.StatusOK, true},
+		{httpheader.ReprDigest, invalid, http.StatusOK, true},
+		{httpheader.ReprDigest, invalid, http.StatusPartialContent, false},
+		{httpheader.ContentDigest, "unixsum=:AQI=:", http.StatusOK, false},
+		{httpheader.ContentDigest, "unixsum=123, " + valid, http.StatusOK, false},
+		{httpheader.ContentDigest, "unixsum=123, " + invalid, http.StatusOK, true},
+		{httpheader.ContentDigest, invalid, http.StatusNotModified, false},
+		{httpheader.ReprDigest, invalid, http.StatusNoContent, false},
+	} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(string(tc.header), tc.value)
+			w.WriteHeader(tc.status)
+			_, _ = io.WriteString(w, body)
+		}))
+
+		c := nxhttp.FromClient(ts.Client(), nxhttp.VerifyDigests())
+		req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		_ = res.Close()
+		ts.Close()
+
+		var dErr nxhttp.DigestError
+		if tc.err {
+			if !errors.As(err, &dErr) || dErr.Header != tc.header || dErr.Algorithm != nxhttp.DigestSHA256 {
+				t.Errorf("#%d: expected a DigestError for %s, but got %v", i, tc.header, err)
+			}
+		} else if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if expected := bodyFor(tc.status, body); string(b) != expected {
+			t.Errorf("#%d: expected body %q, but got %q", i, expected, b)
+		}
+	}
+}
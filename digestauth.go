@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// digestAuthAlgorithm is an algorithm supported by HTTP Digest Access
+// Authentication.
+type digestAuthAlgorithm struct {
+	name    string
+	newHash func() hash.Hash
+}
+
+// digestAuthAlgorithms are the supported algorithms, in order of preference.
+var digestAuthAlgorithms = []digestAuthAlgorithm{
+	{"SHA-512-256", sha512.New512_256},
+	{"SHA-256", sha256.New},
+	{"MD5", md5.New},
+}
+
+// DigestAuth is an [Authenticator] implementing HTTP Digest Access
+// Authentication (RFC 7616).
+//
+// The first request is sent without credentials, once the server responds
+// with a "401 Unauthorized" response containing a Digest challenge, the
+// request is sent again with credentials. Further requests reuse the
+// challenge (incrementing the nonce count) until the server issues a new one.
+//
+// The "SHA-512-256", "SHA-256" and "MD5" algorithms (and their "-sess"
+// variants) are supported, with the strongest algorithm offered by the server
+// being used. If the server offers both the "auth" and "auth-int" qop, "auth"
+// is used, as "auth-int" requires reading the body of the request an
+// additional time to hash it.
+//
+// A DigestAuth should only be used with a single server, as only the most
+// recent challenge is kept.
+type DigestAuth struct {
+	username string
+	password string
+
+	mu        sync.Mutex
+	challenge *digestChallenge
+}
+
+// digestChallenge is a Digest challenge from the server.
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm digestAuthAlgorithm
+	session   bool
+	qop       string
+	userhash  bool
+
+	// nc is the number of requests sent using the nonce.
+	nc uint32
+}
+
+var (
+	_ Authenticator    = (*DigestAuth)(nil)
+	_ ChallengeHandler = (*DigestAuth)(nil)
+)
+
+// NewDigestAuth returns a new [DigestAuth] using the given credentials.
+func NewDigestAuth(username, password string) *DigestAuth {
+	return &DigestAuth{username: username, password: password}
+}
+
+// Authenticate sets the "Authorization" header of req, if a challenge has been
+// received from the server.
+func (a *DigestAuth) Authenticate(_ context.Context, req *Request) error {
+	a.mu.Lock()
+	c := a.challenge
+	if c == nil {
+		a.mu.Unlock()
+		return nil
+	}
+	c.nc++
+	nc := c.nc
+	a.mu.Unlock()
+
+	v, err := a.authorization(c, nc, req)
+	if err != nil {
+		return err
+	}
+	req.SetHeader(httpheader.Authorization, v)
+	return nil
+}
+
+// HandleChallenge parses the Digest challenge of res, returning whether the
+// request should be sent again using it.
+func (a *DigestAuth) HandleChallenge(_ context.Context, req *Request, res *Response) (bool, error) {
+	challenges, err := httpheader.ParseChallenges(httpheader.Values(res.Header, httpheader.WWWAuthenticate)...)
+	if err != nil {
+		return false, err
+	}
+	c, stale, ok := newDigestChallenge(challenges)
+	if !ok {
+		return false, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// If the request was already authenticated using the same nonce, and the
+	// server didn't indicate the nonce is stale, the credentials are wrong and
+	// sending the request again won't help.
+	if httpheader.Get(req.Header, httpheader.Authorization) != "" && !stale &&
+		a.challenge != nil && a.challenge.nonce == c.nonce {
+		return false, nil
+	}
+	a.challenge = c
+	return true, nil
+}
+
+// newDigestChallenge returns the Digest challenge using the strongest
+// supported algorithm from challenges, and whether the server indicated the
+// previous nonce is stale.
+func newDigestChallenge(challenges []httpheader.Challenge) (*digestChallenge, bool, bool) {
+	var (
+		best  *digestChallenge
+		stale bool
+		rank  = len(digestAuthAlgorithms)
+	)
+	for _, ch := range challenges {
+		if !strings.EqualFold(ch.Scheme, "Digest") || ch.Params["nonce"] == "" {
+			continue
+		}
+
+		name := ch.Params["algorithm"]
+		if name == "" {
+			name = "MD5"
+		}
+		name, session := strings.CutSuffix(strings.ToUpper(name), "-SESS")
+		i := slices.IndexFunc(digestAuthAlgorithms, func(alg digestAuthAlgorithm) bool { return alg.name == name })
+		if i < 0 || i >= rank {
+			continue
+		}
+
+		var qop string
+		if v, ok := ch.Params["qop"]; ok {
+			options := strings.Split(v, ",")
+			for i := range options {
+				options[i] = strings.TrimSpace(options[i])
+			}
+			switch {
+			case slices.Contains(options, "auth"):
+				qop = "auth"
+			case slices.Contains(options, "auth-int"):
+				qop = "auth-int"
+			default:
+				continue
+			}
+		}
+
+		rank = i
+		stale = strings.EqualFold(ch.Params["stale"], "true")
+		best = &digestChallenge{
+			realm:     ch.Params["realm"],
+			nonce:     ch.Params["nonce"],
+			opaque:    ch.Params["opaque"],
+			algorithm: digestAuthAlgorithms[i],
+			session:   session,
+			qop:       qop,
+			userhash:  strings.EqualFold(ch.Params["userhash"], "true"),
+		}
+	}
+	return best, stale, best != nil
+}
+
+// authorization returns the value of the "Authorization" header for req.
+func (a *DigestAuth) authorization(c *digestChallenge, nc uint32, req *Request) (string, error) {
+	h := func(parts ...string) string {
+		hh := c.algorithm.newHash()
+		_, _ = io.WriteString(hh, strings.Join(parts, ":"))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	cnonce := rand.Text()
+	ncValue := fmt.Sprintf("%08x", nc)
+	uri := req.URL.RequestURI()
+
+	ha1 := h(a.username, c.realm, a.password)
+	if c.session {
+		ha1 = h(ha1, c.nonce, cnonce)
+	}
+
+	ha2 := h(req.Method, uri)
+	if c.qop == "auth-int" {
+		bodyHash, err := a.hashBody(c, req)
+		if err != nil {
+			return "", err
+		}
+		ha2 = h(req.Method, uri, bodyHash)
+	}
+
+	var response string
+	if c.qop == "" {
+		response = h(ha1, c.nonce, ha2)
+	} else {
+		response = h(ha1, c.nonce, ncValue, cnonce, c.qop, ha2)
+	}
+
+	username := a.username
+	if c.userhash {
+		username = h(a.username, c.realm)
+	}
+
+	algorithm := c.algorithm.name
+	if c.session {
+		algorithm += "-sess"
+	}
+
+	var b strings.Builder
+	b.WriteString("Digest ")
+	b.WriteString(`username=` + quoteString(username))
+	b.WriteString(`, realm=` + quoteString(c.realm))
+	b.WriteString(`, uri=` + quoteString(uri))
+	b.WriteString(`, algorithm=` + algorithm)
+	b.WriteString(`, nonce=` + quoteString(c.nonce))
+	if c.qop != "" {
+		b.WriteString(`, nc=` + ncValue)
+		b.WriteString(`, cnonce=` + quoteString(cnonce))
+		b.WriteString(`, qop=` + c.qop)
+	}
+	b.WriteString(`, response=` + quoteString(response))
+	if c.opaque != "" {
+		b.WriteString(`, opaque=` + quoteString(c.opaque))
+	}
+	if c.userhash {
+		b.WriteString(`, userhash=true`)
+	}
+	return b.String(), nil
+}
+
+// hashBody returns the hash of the body of req, used by the "auth-int" qop.
+func (a *DigestAuth) hashBody(c *digestChallenge, req *Request) (string, error) {
+	hh := c.algorithm.newHash()
+	if req.body != nil {
+		body, err := req.body()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		if _, err := io.Copy(hh, body); err != nil {
+			return "", fmt.Errorf("nxhttp: failed to hash body: %w", err)
+		}
+	}
+	return hex.EncodeToString(hh.Sum(nil)), nil
+}
+
+// quotedStringEscaper escapes the characters that must be escaped within a
+// quoted-string.
+var quotedStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// quoteString returns s as a quoted-string (RFC 9110, Section 5.6.4).
+func quoteString(s string) string {
+	return `"` + quotedStringEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/matthewpi/nxhttp"
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+func TestParseChallenges(t *testing.T) {
+	challenges, err := httpheader.ParseChallenges(
+		`Newauth realm="apps", type=1, title="Login to \"apps\"", Basic realm="simple"`,
+		`Bearer abc123==, Negotiate`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(challenges) != 4 {
+		t.Fatalf("expected 4 challenges, but got %d: %+v", len(challenges), challenges)
+	}
+	if c := challenges[0]; c.Scheme != "Newauth" || c.Params["title"] != `Login to "apps"` || c.Params["type"] != "1" {
+		t.Errorf("unexpected challenge %+v", c)
+	}
+	if c := challenges[1]; c.Scheme != "Basic" || c.Params["realm"] != "simple" {
+		t.Errorf("unexpected challenge %+v", c)
+	}
+	if c := challenges[2]; c.Scheme != "Bearer" || c.Token68 != "abc123==" {
+		t.Errorf("unexpected challenge %+v", c)
+	}
+	if c := challenges[3]; c.Scheme != "Negotiate" {
+		t.Errorf("unexpected challenge %+v", c)
+	}
+
+	if _, err := httpheader.ParseChallenges(`Digest realm="unterminated`); err == nil {
+		t.Error("expected an error for an unterminated quoted-string")
+	}
+}
+
+// The credentials and challenge used by [digestServer], from the example in
+// RFC 7616, Section 3.9.1.
+const (
+	digestRealm    = "http-auth@example.org"
+	digestUsername = "Mufasa"
+	digestPassword = "Circle of Life"
+	digestNonce    = "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v"
+	digestOpaque   = "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"
+)
+
+// digestServer is a server that requires HTTP Digest Authentication.
+type digestServer struct {
+	algorithm string
+	qop       string
+	newHash   func() hash.Hash
+	userhash  bool
+	// rotate is the number of requests a nonce can be used for before the
+	// server issues a new one, or 0 if the nonce never changes.
+	rotate int
+	// stale indicates replaced nonces are reported as stale.
+	stale bool
+
+	mu sync.Mutex
+	// nonce is the current nonce, and uses the number of requests that were
+	// authenticated using it.
+	nonce string
+	uses  int
+	// requests is the number of requests received.
+	requests int
+	// nc is the nonce counts received.
+	nc []string
+}
+
+func (s *digestServer) h(parts ...string) string {
+	h := s.newHash()
+	_, _ = io.WriteString(h, strings.Join(parts, ":"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// response returns the expected response to a challenge using nonce.
+func (s *digestServer) response(nonce, nc, cnonce, qop, method, uri, body string) string {
+	ha1 := s.h(digestUsername, digestRealm, digestPassword)
+	if strings.HasSuffix(s.algorithm, "-sess") {
+		ha1 = s.h(ha1, nonce, cnonce)
+	}
+	ha2 := s.h(method, uri)
+	if qop == "auth-int" {
+		ha2 = s.h(method, uri, s.h(body))
+	}
+	return s.h(ha1, nonce, nc, cnonce, qop, ha2)
+}
+
+// challenge writes a "401 Unauthorized" response with a Digest challenge
+// using the current nonce.
+func (s *digestServer) challenge(w http.ResponseWriter, stale bool) {
+	v := `Basic realm="other", Digest realm="` + digestRealm + `", qop="` + s.qop + `", algorithm=` + s.algorithm + `, nonce="` + s.nonce + `", opaque="` + digestOpaque + `"`
+	if s.userhash {
+		v += ", userhash=true"
+	}
+	if stale {
+		v += ", stale=true"
+	}
+	w.Header().Set("WWW-Authenticate", v)
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.nonce == "" {
+		s.nonce = digestNonce
+	}
+
+	challenges, err := httpheader.ParseChallenges(r.Header.Get("Authorization"))
+	if err != nil || len(challenges) != 1 {
+		s.challenge(w, false)
+		return
+	}
+	p := challenges[0].Params
+	if p["nonce"] != s.nonce {
+		s.challenge(w, s.stale)
+		return
+	}
+
+	username := digestUsername
+	if s.userhash {
+		username = s.h(digestUsername, digestRealm)
+	}
+	expected := s.response(s.nonce, p["nc"], p["cnonce"], p["qop"], r.Method, r.URL.RequestURI(), string(body))
+	if p["username"] != username || (p["userhash"] == "true") != s.userhash ||
+		p["response"] != expected || p["opaque"] != digestOpaque || p["uri"] != r.URL.RequestURI() {
+		s.challenge(w, false)
+		return
+	}
+
+	s.nc = append(s.nc, p["nc"])
+	if s.uses++; s.uses == s.rotate {
+		s.nonce, s.uses = rand.Text(), 0
+	}
+	_, _ = w.Write(body)
+}
+
+func TestDigestAuth_RFC7616(t *testing.T) {
+	// Ensure the test server computes the same responses as the examples in
+	// RFC 7616, Section 3.9.1, as it is used to verify DigestAuth.
+	for _, tc := range []struct {
+		algorithm string
+		newHash   func() hash.Hash
+		expected  string
+	}{
+		{"MD5", md5.New, "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", sha256.New, "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	} {
+		s := &digestServer{algorithm: tc.algorithm, newHash: tc.newHash}
+		got := s.response(digestNonce, "00000001", "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", "auth", http.MethodGet, "/dir/index.html", "")
+		if got != tc.expected {
+			t.Errorf("%s: expected response to be '%s', but got '%s'", tc.algorithm, tc.expected, got)
+		}
+	}
+}
+
+func TestDigestAuth(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		algorithm string
+		qop       string
+		newHash   func() hash.Hash
+		userhash  bool
+		rotate    int
+		stale     bool
+		// requests and nc are the number of requests and the nonce counts
+		// received by the server using the correct password.
+		requests int
+		nc       string
+	}{
+		{name: "SHA-256", algorithm: "SHA-256", qop: "auth, auth-int", newHash: sha256.New, requests: 3, nc: "00000001,00000002"},
+		{name: "MD5-sess auth-int", algorithm: "MD5-sess", qop: "auth-int", newHash: md5.New, requests: 3, nc: "00000001,00000002"},
+		{name: "SHA-512-256 userhash", algorithm: "SHA-512-256", qop: "auth", newHash: sha512.New512_256, userhash: true, requests: 3, nc: "00000001,00000002"},
+		{name: "nonce rotation", algorithm: "SHA-256", qop: "auth", newHash: sha256.New, rotate: 1, requests: 4, nc: "00000001,00000001"},
+		{name: "stale nonce", algorithm: "SHA-256", qop: "auth", newHash: sha256.New, rotate: 1, stale: true, requests: 4, nc: "00000001,00000001"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, creds := range []struct {
+				password string
+				status   int
+				requests int
+			}{
+				// With the wrong password, the server rejects the credentials
+				// using the same nonce, so the request must not be sent again.
+				{"wrong", http.StatusUnauthorized, 3},
+				{digestPassword, http.StatusOK, tc.requests},
+			} {
+				s := &digestServer{
+					algorithm: tc.algorithm,
+					qop:       tc.qop,
+					newHash:   tc.newHash,
+					userhash:  tc.userhash,
+					rotate:    tc.rotate,
+					stale:     tc.stale,
+				}
+				ts := httptest.NewServer(s)
+				defer ts.Close()
+
+				c := nxhttp.FromClient(ts.Client(), nxhttp.WithAuthenticator(nxhttp.NewDigestAuth(digestUsername, creds.password)))
+				for range 2 {
+					req, err := nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL+"/dir/index.html?a=b", strings.NewReader("body"))
+					if err != nil {
+						t.Fatal(err)
+					}
+					res, err := c.Do(req)
+					if err != nil {
+						t.Fatal(err)
+					}
+					b, _ := io.ReadAll(res.Body)
+					_ = res.Close()
+					if res.StatusCode != creds.status {
+						t.Fatalf("expected a %d response, but got %d", creds.status, res.StatusCode)
+					}
+					if creds.status == http.StatusOK && string(b) != "body" {
+						t.Errorf("expected body to be 'body', but got '%s'", b)
+					}
+				}
+
+				s.mu.Lock()
+				requests, nc := s.requests, strings.Join(s.nc, ",")
+				s.mu.Unlock()
+				if requests != creds.requests {
+					t.Errorf("expected %d requests, but got %d", creds.requests, requests)
+				}
+				// The nonce count must increase with every request using the
+				// nonce, and restart once the server issues a new one.
+				if creds.status == http.StatusOK && nc != tc.nc {
+					t.Errorf("expected nonce counts to be '%s', but got '%s'", tc.nc, nc)
+				}
+			}
+		})
+	}
+}
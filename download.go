@@ -0,0 +1,495 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+	"github.com/matthewpi/nxretry"
+)
+
+// defaultDownloadChunkSize is the default size of the ranges requested by a
+// parallel download.
+const defaultDownloadChunkSize = 8 * 1024 * 1024
+
+// downloadOptions represent the options for [Client.Download].
+type downloadOptions struct {
+	// requestOptions used for every request made by the download.
+	requestOptions []RequestOption
+	// concurrency is the maximum number of ranges to download at once.
+	concurrency int
+	// chunkSize is the size of each range of a parallel download.
+	chunkSize int64
+	// progress is called as the download progresses.
+	progress ProgressFunc
+}
+
+// DownloadOption for [Client.Download].
+type DownloadOption interface {
+	// applyDownload applies the [DownloadOption] to a [downloadOptions]
+	// instance.
+	applyDownload(*downloadOptions)
+}
+
+// DownloadOptionFunc type is an adapter to allow the use of ordinary functions
+// as a [DownloadOption]. If f is a function with the appropriate signature,
+// `DownloadOptionFunc(f)` is a [DownloadOption] that calls f.
+type DownloadOptionFunc func(o *downloadOptions)
+
+// Ensure that [DownloadOptionFunc] implements the [DownloadOption] interface.
+var _ DownloadOption = (*DownloadOptionFunc)(nil)
+
+// applyDownload applies the [DownloadOption] to a [downloadOptions] instance.
+func (f DownloadOptionFunc) applyDownload(o *downloadOptions) { f(o) }
+
+// WithDownloadRequestOptions sets the [RequestOption] used for every request
+// made by a download.
+//
+// The [nxretry.Backoff] and maximum number of attempts of the options are also
+// used when resuming the download after a failure.
+func WithDownloadRequestOptions(opts ...RequestOption) DownloadOptionFunc {
+	return func(o *downloadOptions) { o.requestOptions = append(o.requestOptions, opts...) }
+}
+
+// WithDownloadConcurrency enables parallel downloads, where up to n ranges of
+// the resource are downloaded at once.
+//
+// Parallel downloads are only used if a HEAD request for the resource shows
+// the server supports range requests ("Accept-Ranges: bytes"), the size of
+// the resource is known, and the resource has a strong "ETag" or a
+// "Last-Modified" date, otherwise the resource is downloaded using a single
+// request.
+func WithDownloadConcurrency(n int) DownloadOptionFunc {
+	return func(o *downloadOptions) { o.concurrency = n }
+}
+
+// WithDownloadChunkSize sets the size of the ranges requested by a parallel
+// download. Defaults to 8 MiB.
+func WithDownloadChunkSize(size int64) DownloadOptionFunc {
+	return func(o *downloadOptions) {
+		if size > 0 {
+			o.chunkSize = size
+		}
+	}
+}
+
+// WithDownloadProgress sets a function that is called whenever data is
+// written to the destination of a download.
+//
+// Calls to fn are serialized, even when downloading in parallel. If part of a
+// download has to be restarted, the number of bytes transferred may decrease.
+func WithDownloadProgress(fn ProgressFunc) DownloadOptionFunc {
+	return func(o *downloadOptions) { o.progress = fn }
+}
+
+// Download downloads the contents of url into dst, returning the number of
+// bytes that were downloaded.
+//
+// If the download fails while reading the response body, it is resumed from
+// where it left off using a "Range" request. The "If-Range" header is used to
+// ensure the resource has not changed in the meantime, if the server responds
+// with the entire resource instead, the download restarts from the beginning.
+// A response containing less than the rest of the resource is resumed the
+// same way. The delay between resumes is controlled by the configured
+// [nxretry.Backoff] and the number of resumes is limited by the maximum number
+// of attempts.
+//
+// If [WithDownloadConcurrency] is used and the server supports it, the
+// resource is split into ranges that are downloaded in parallel, each of which
+// is resumed individually. If the server responds to a range with a different
+// version of the resource, the download fails.
+//
+// If dst has a `Truncate(int64) error` method (such as [*os.File]), it is
+// truncated to the size of the download once it completes.
+func (c *Client) Download(ctx context.Context, url string, dst io.WriterAt, opts ...DownloadOption) (int64, error) {
+	d := c.newDownload(url, dst, opts)
+
+	var (
+		n   int64
+		err error
+	)
+	if size, hErr := d.head(ctx); hErr != nil {
+		return 0, hErr
+	} else if size > 0 {
+		n, err = d.fetchParallel(ctx, size)
+	} else {
+		n, err = d.fetch(ctx, 0, -1)
+	}
+	if err != nil {
+		return n, err
+	}
+	if t, ok := dst.(interface{ Truncate(int64) error }); ok {
+		if err := t.Truncate(n); err != nil {
+			return n, fmt.Errorf("nxhttp: failed to truncate download: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// download is a single download started by [Client.Download].
+type download struct {
+	client *Client
+	url    string
+	dst    io.WriterAt
+	opts   *downloadOptions
+
+	// reqOpts are the resolved request options, used for the retry options.
+	reqOpts *requestOptions
+	// validator of the resource from the HEAD request of a parallel download.
+	validator string
+
+	// mu guards transferred and total.
+	mu          sync.Mutex
+	transferred int64
+	total       int64
+}
+
+// newDownload returns a new [download].
+func (c *Client) newDownload(url string, dst io.WriterAt, opts []DownloadOption) *download {
+	o := &downloadOptions{chunkSize: defaultDownloadChunkSize}
+	for _, opt := range opts {
+		opt.applyDownload(o)
+	}
+	reqOpts := c.requestOptions()
+	for _, opt := range o.requestOptions {
+		opt.apply(reqOpts)
+	}
+	return &download{
+		client:  c,
+		url:     url,
+		dst:     dst,
+		opts:    o,
+		reqOpts: reqOpts,
+		total:   -1,
+	}
+}
+
+// progress records n bytes being written to the destination, or removed if n
+// is negative.
+func (d *download) progress(n int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.transferred += n
+	if d.opts.progress != nil {
+		d.opts.progress(d.transferred, d.total)
+	}
+}
+
+// setTotal sets the total size of the download if it isn't already known.
+func (d *download) setTotal(size int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.total < 0 {
+		d.total = size
+	}
+}
+
+// head determines whether the resource can be downloaded in parallel, and if
+// so, returns its size. A size of zero indicates the resource should be
+// downloaded using a single request.
+func (d *download) head(ctx context.Context) (int64, error) {
+	if d.opts.concurrency <= 1 {
+		return 0, nil
+	}
+
+	req, err := NewRequest(ctx, http.MethodHead, d.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.SetHeader(httpheader.AcceptEncoding, "identity")
+	res, err := d.client.Do(req, d.opts.requestOptions...)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Close()
+
+	// Some servers don't support HEAD requests, so rather than failing
+	// the download, fallback to a single request.
+	if res.StatusCode != http.StatusOK ||
+		res.GetHeader(httpheader.AcceptRanges) != "bytes" ||
+		res.GetHeader(httpheader.ContentEncoding) != "" ||
+		res.ContentLength <= d.opts.chunkSize {
+		return 0, nil
+	}
+
+	// Ensure every range is of the same version of the resource, without a
+	// validator we can't detect the resource changing mid-download, in which
+	// case the ranges could be stitched together from different versions.
+	d.validator = responseValidator(res)
+	if d.validator == "" {
+		return 0, nil
+	}
+	d.setTotal(res.ContentLength)
+	return res.ContentLength, nil
+}
+
+// fetchParallel downloads a resource of the given size by splitting it into
+// ranges that are downloaded concurrently.
+func (d *download) fetchParallel(ctx context.Context, size int64) (int64, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	chunks := make(chan int64)
+	workers := min(int64(d.opts.concurrency), (size+d.opts.chunkSize-1)/d.opts.chunkSize)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for start := range chunks {
+				end := min(start+d.opts.chunkSize, size) - 1
+				if _, err := d.fetch(ctx, start, end); err != nil {
+					cancel(err)
+					return
+				}
+			}
+		})
+	}
+
+send:
+	for start := int64(0); start < size; start += d.opts.chunkSize {
+		select {
+		case chunks <- start:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(chunks)
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.transferred, err
+	}
+	return size, nil
+}
+
+// fetch downloads the range of bytes from start to end (inclusive) into the
+// destination at the same offset, resuming the download if reading the
+// response body fails. If end is negative, the rest of the resource starting
+// at start is downloaded.
+//
+// It returns the number of bytes that were written to the destination.
+func (d *download) fetch(ctx context.Context, start, end int64) (int64, error) {
+	r := &rangeFetch{download: d, start: start, end: end, offset: start, validator: d.validator}
+	rty := nxretry.New(
+		nxretry.MaxAttempts(d.reqOpts.maxAttempts),
+		d.reqOpts.backoff,
+	)
+	var err error
+	for range rty.Next(ctx) {
+		err = r.do(ctx)
+		if err == nil {
+			return r.offset - start, nil
+		}
+
+		// Only resume the download if reading the body failed.
+		var rErr resumableError
+		if !errors.As(err, &rErr) || ctx.Err() != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return r.offset - start, err
+}
+
+// rangeFetch is the state of a range being downloaded by [download.fetch].
+type rangeFetch struct {
+	*download
+
+	// start and end of the range.
+	start, end int64
+	// offset of the next byte to download.
+	offset int64
+	// validator is the strong ETag (or Last-Modified date) of the resource
+	// used in the "If-Range" header when resuming.
+	validator string
+}
+
+// do sends a request for the rest of the range, writing the response body to
+// the destination.
+func (r *rangeFetch) do(ctx context.Context) error {
+	req, err := NewRequest(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	// Ranges apply to the encoded content, so ask for the content as-is to
+	// ensure offsets into the response match offsets into the destination.
+	req.SetHeader(httpheader.AcceptEncoding, "identity")
+	ranged := r.offset > 0 || r.end >= 0
+	if ranged {
+		v := "bytes=" + strconv.FormatInt(r.offset, 10) + "-"
+		if r.end >= 0 {
+			v += strconv.FormatInt(r.end, 10)
+		}
+		req.SetHeader(httpheader.Range, v)
+		if r.validator != "" {
+			req.SetHeader(httpheader.IfRange, r.validator)
+		}
+	}
+
+	res, err := r.client.Do(req, r.opts.requestOptions...)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	// last is the offset of the last byte the response must contain, or
+	// negative if it isn't known.
+	last := r.end
+	validator := responseValidator(res)
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		if !ranged {
+			return fmt.Errorf("nxhttp: got unexpected partial content for '%s'", r.url)
+		}
+		start, end, size, err := parseContentRange(res.GetHeader(httpheader.ContentRange))
+		if err != nil {
+			return err
+		}
+		if start != r.offset || (r.end >= 0 && end != r.end) {
+			return fmt.Errorf("nxhttp: got unexpected content range %d-%d, expected %d-%d", start, end, r.offset, r.end)
+		}
+		// A server that ignores the "If-Range" header may respond with a
+		// range of a different version of the resource, which must not be
+		// stitched together with what was already downloaded.
+		if r.validator != "" && validator != "" && validator != r.validator {
+			return fmt.Errorf("nxhttp: '%s' changed during download", r.url)
+		}
+		// The server may respond with less than the rest of the resource,
+		// in which case the download is resumed from where it left off.
+		if last < 0 && size >= 0 {
+			last = size - 1
+		}
+		r.setTotal(size)
+	case http.StatusOK:
+		// The server either doesn't support range requests, or the resource
+		// changed since the download started, either way, we got the entire
+		// resource and need to start over.
+		if r.start > 0 || r.end >= 0 {
+			if r.validator != "" {
+				return fmt.Errorf("nxhttp: '%s' changed during download", r.url)
+			}
+			return fmt.Errorf("nxhttp: server does not support range requests for '%s'", r.url)
+		}
+		r.setTotal(res.ContentLength)
+		if r.offset > 0 {
+			r.progress(-r.offset)
+			r.offset = 0
+		}
+		last = res.ContentLength - 1
+		r.validator = validator
+	default:
+		return NewStatusError(res, http.StatusOK, http.StatusPartialContent)
+	}
+	if r.validator == "" {
+		r.validator = validator
+	}
+
+	w := &progressWriter{w: io.NewOffsetWriter(r.dst, r.offset), d: r.download}
+	n, err := io.Copy(w, res.Body)
+	r.offset += n
+	if err != nil {
+		// Only failing to read the response body can be resumed, failing to
+		// write to the destination is final.
+		if w.err != nil {
+			return fmt.Errorf("nxhttp: failed to write download: %w", w.err)
+		}
+		return resumableError{err: err}
+	}
+	if last >= 0 && r.offset != last+1 {
+		return resumableError{err: io.ErrUnexpectedEOF}
+	}
+	return nil
+}
+
+// responseValidator returns the strong ETag of res, or its Last-Modified date
+// if it doesn't have one, as weak ETags cannot be used with the "If-Range"
+// header.
+func responseValidator(res *Response) string {
+	if etag := res.GetHeader(httpheader.ETag); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return res.GetHeader(httpheader.LastModified)
+}
+
+// progressWriter is an [io.Writer] that reports the progress of a download.
+type progressWriter struct {
+	w io.Writer
+	d *download
+
+	// err returned by the underlying [io.Writer], if any.
+	err error
+}
+
+// Write writes p to the underlying [io.Writer], reporting the number of bytes
+// written to the download.
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.d.progress(int64(n))
+	}
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// resumableError indicates a download failed while reading the response body
+// and can be resumed.
+type resumableError struct {
+	err error
+}
+
+// Error returns an error message and satisfies the [error] interface.
+func (e resumableError) Error() string {
+	return "nxhttp: download interrupted: " + e.err.Error()
+}
+
+// Unwrap returns the underlying [error] that caused the [resumableError].
+func (e resumableError) Unwrap() error {
+	return e.err
+}
+
+// parseContentRange parses a "Content-Range" header in the form of
+// "bytes start-end/size", where size may be "*" if it is unknown (-1).
+func parseContentRange(v string) (start, end, size int64, err error) {
+	unit, rng, ok := strings.Cut(v, " ")
+	if !ok || unit != "bytes" {
+		return 0, 0, 0, fmt.Errorf("nxhttp: malformed Content-Range header '%s'", v)
+	}
+	rng, sizeStr, ok := strings.Cut(rng, "/")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("nxhttp: malformed Content-Range header '%s'", v)
+	}
+	startStr, endStr, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("nxhttp: malformed Content-Range header '%s'", v)
+	}
+	if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("nxhttp: malformed Content-Range header '%s': %w", v, err)
+	}
+	if end, err = strconv.ParseInt(endStr, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("nxhttp: malformed Content-Range header '%s': %w", v, err)
+	}
+	size = -1
+	if sizeStr != "*" {
+		if size, err = strconv.ParseInt(sizeStr, 10, 64); err != nil {
+			return 0, 0, 0, fmt.Errorf("nxhttp: malformed Content-Range header '%s': %w", v, err)
+		}
+	}
+	if start < 0 || end < start || (size >= 0 && end >= size) {
+		return 0, 0, 0, fmt.Errorf("nxhttp: invalid Content-Range header '%s'", v)
+	}
+	return start, end, size, nil
+}
@@ -0,0 +1,357 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+// abortWriter is a [http.ResponseWriter] that aborts the response after limit
+// bytes of the body have been written.
+type abortWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (w *abortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		_, _ = w.ResponseWriter.Write(p[:w.limit])
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	w.limit -= len(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// downloadTestServer serves content, aborting the response to the first GET
+// request after abortAfter bytes were written.
+type downloadTestServer struct {
+	content    []byte
+	abortAfter int
+
+	mu       sync.Mutex
+	etag     string
+	requests []*http.Request
+	aborted  bool
+	// changeETag changes the ETag of the content after the first request.
+	changeETag bool
+}
+
+func (s *downloadTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	abort := r.Method == http.MethodGet && !s.aborted
+	if abort {
+		s.aborted = true
+	}
+	etag := s.etag
+	if s.changeETag {
+		s.etag = `"v2"`
+	}
+	s.mu.Unlock()
+
+	if abort {
+		w = &abortWriter{ResponseWriter: w, limit: s.abortAfter}
+	}
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.content))
+}
+
+func TestClient_Download(t *testing.T) {
+	content := make([]byte, 64*1024)
+	_, _ = rand.Read(content)
+
+	for _, tc := range []struct {
+		name       string
+		changeETag bool
+	}{
+		{"resume", false},
+		{"restart", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &downloadTestServer{content: content, abortAfter: len(content) / 2, etag: `"v1"`, changeETag: tc.changeETag}
+			ts := httptest.NewServer(s)
+			defer ts.Close()
+
+			// Pre-fill the destination with more data than will be downloaded
+			// to ensure it gets truncated.
+			f, err := os.Create(filepath.Join(t.TempDir(), "download"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if _, err := f.Write(make([]byte, 2*len(content))); err != nil {
+				t.Fatal(err)
+			}
+
+			c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+			n, err := c.Download(context.Background(), ts.URL, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(content)) {
+				t.Errorf("expected %d bytes to be downloaded, but got %d", len(content), n)
+			}
+			b, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, content) {
+				t.Errorf("downloaded content does not match (got %d bytes)", len(b))
+			}
+
+			if len(s.requests) != 2 {
+				t.Fatalf("expected 2 requests, but got %d", len(s.requests))
+			}
+			r := s.requests[1]
+			if got, expected := r.Header.Get("Range"), "bytes=32768-"; got != expected {
+				t.Errorf("expected Range header to be '%s', but got '%s'", expected, got)
+			}
+			if got, expected := r.Header.Get("If-Range"), `"v1"`; got != expected {
+				t.Errorf("expected If-Range header to be '%s', but got '%s'", expected, got)
+			}
+		})
+	}
+}
+
+func TestClient_Download_Parallel(t *testing.T) {
+	content := make([]byte, 64*1024)
+	_, _ = rand.Read(content)
+
+	s := &downloadTestServer{content: content, abortAfter: 1024, etag: `"v1"`}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	var (
+		mu          sync.Mutex
+		transferred int64
+		total       int64
+	)
+	dst := &writerAt{}
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+	n, err := c.Download(
+		context.Background(),
+		ts.URL,
+		dst,
+		nxhttp.WithDownloadConcurrency(4),
+		nxhttp.WithDownloadChunkSize(8*1024),
+		nxhttp.WithDownloadProgress(func(n, t int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			transferred, total = n, t
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("expected %d bytes to be downloaded, but got %d", len(content), n)
+	}
+	if !bytes.Equal(dst.b, content) {
+		t.Errorf("downloaded content does not match (got %d bytes)", len(dst.b))
+	}
+	if transferred != n || total != n {
+		t.Errorf("expected progress to be %d/%d, but got %d/%d", n, n, transferred, total)
+	}
+
+	// 1 HEAD, 8 chunks and 1 resumed chunk.
+	if len(s.requests) != 10 {
+		t.Errorf("expected 10 requests, but got %d", len(s.requests))
+	}
+	for _, r := range s.requests[1:] {
+		if r.Header.Get("Range") == "" || r.Header.Get("If-Range") != `"v1"` {
+			t.Errorf("expected a conditional range request, but got Range '%s' and If-Range '%s'", r.Header.Get("Range"), r.Header.Get("If-Range"))
+		}
+	}
+}
+
+// writerAt is an in-memory [io.WriterAt].
+type writerAt struct {
+	mu sync.Mutex
+	b  []byte
+}
+
+func (w *writerAt) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if end := int(off) + len(p); end > len(w.b) {
+		w.b = append(w.b, make([]byte, end-len(w.b))...)
+	}
+	return copy(w.b[off:], p), nil
+}
+
+// errWriterAt is an [io.WriterAt] that always fails.
+type errWriterAt struct{ err error }
+
+func (w errWriterAt) WriteAt([]byte, int64) (int, error) { return 0, w.err }
+
+func TestClient_Download_WriteError(t *testing.T) {
+	content := make([]byte, 64*1024)
+	_, _ = rand.Read(content)
+
+	s := &downloadTestServer{content: content, abortAfter: len(content), etag: `"v1"`}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	writeErr := errors.New("disk full")
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+	_, err := c.Download(context.Background(), ts.URL, errWriterAt{err: writeErr})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("expected the write error, but got %v", err)
+	}
+	if len(s.requests) != 1 {
+		t.Errorf("expected a failed write to not be resumed, but got %d requests", len(s.requests))
+	}
+}
+
+func TestClient_Download_NoValidator(t *testing.T) {
+	content := make([]byte, 64*1024)
+	_, _ = rand.Read(content)
+
+	s := &downloadTestServer{content: content, abortAfter: len(content)}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	dst := &writerAt{}
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+	n, err := c.Download(
+		context.Background(),
+		ts.URL,
+		dst,
+		nxhttp.WithDownloadConcurrency(4),
+		nxhttp.WithDownloadChunkSize(8*1024),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(content)) || !bytes.Equal(dst.b, content) {
+		t.Errorf("downloaded content does not match (got %d bytes)", len(dst.b))
+	}
+
+	// Without a validator, the resource must be downloaded using a single
+	// request rather than in parallel.
+	if len(s.requests) != 2 {
+		t.Fatalf("expected 2 requests, but got %d", len(s.requests))
+	}
+	if v := s.requests[1].Header.Get("Range"); v != "" {
+		t.Errorf("expected no Range header, but got '%s'", v)
+	}
+}
+
+func TestClient_Download_ShortRange(t *testing.T) {
+	content := make([]byte, 64*1024)
+	_, _ = rand.Read(content)
+
+	// The server aborts the first response halfway through, and responds to
+	// every range request with at most 8 KiB, regardless of the requested
+	// range.
+	const chunkSize = 8 * 1024
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		w.Header().Set("ETag", `"v1"`)
+		var start int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err != nil {
+			w = &abortWriter{ResponseWriter: w, limit: len(content) / 2}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			return
+		}
+		end := min(start+chunkSize, len(content))
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, len(content)))
+		w.Header().Set("Content-Length", strconv.Itoa(end-start))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(content[start:end])
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+	for _, tc := range []struct {
+		attempts uint
+		err      bool
+	}{
+		// The rest of the content takes 4 range requests.
+		{attempts: 5},
+		{attempts: 3, err: true},
+	} {
+		mu.Lock()
+		requests = 0
+		mu.Unlock()
+
+		dst := &writerAt{}
+		n, err := c.Download(
+			context.Background(),
+			ts.URL,
+			dst,
+			nxhttp.WithDownloadRequestOptions(nxhttp.WithRequestMaxAttempts(tc.attempts)),
+		)
+		if tc.err {
+			// The download must not be silently truncated once the attempts
+			// are used up.
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("%d attempts: expected an unexpected EOF error, but got %v", tc.attempts, err)
+			}
+		} else if err != nil {
+			t.Errorf("%d attempts: unexpected error: %v", tc.attempts, err)
+		} else if n != int64(len(content)) || !bytes.Equal(dst.b, content) {
+			t.Errorf("%d attempts: downloaded content does not match (got %d bytes)", tc.attempts, n)
+		}
+		mu.Lock()
+		if requests != int(tc.attempts) {
+			t.Errorf("%d attempts: expected %d requests, but got %d", tc.attempts, tc.attempts, requests)
+		}
+		mu.Unlock()
+	}
+}
+
+func TestClient_Download_ChangedRange(t *testing.T) {
+	content := make([]byte, 64*1024)
+	_, _ = rand.Read(content)
+
+	// The server ignores the "If-Range" header, and serves one of the ranges
+	// from a different version of the resource.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v1"`
+		if strings.HasPrefix(r.Header.Get("Range"), "bytes=16384-") {
+			etag = `"v2"`
+		}
+		r.Header.Del("If-Range")
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+	_, err := c.Download(
+		context.Background(),
+		ts.URL,
+		&writerAt{},
+		nxhttp.WithDownloadConcurrency(4),
+		nxhttp.WithDownloadChunkSize(8*1024),
+	)
+	if err == nil || !strings.Contains(err.Error(), "changed during download") {
+		t.Errorf("expected the download to fail as the resource changed, but got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// Encoding is a content coding used with the "Content-Encoding" header.
+type Encoding string
+
+const (
+	// EncodingGzip is the "gzip" content coding.
+	EncodingGzip Encoding = "gzip"
+	// EncodingDeflate is the "deflate" content coding, which despite its name
+	// uses the zlib format.
+	EncodingDeflate Encoding = "deflate"
+	// EncodingBrotli is the "br" (Brotli) content coding.
+	EncodingBrotli Encoding = "br"
+	// EncodingZstd is the "zstd" (Zstandard) content coding.
+	EncodingZstd Encoding = "zstd"
+)
+
+// acceptEncoding is the value of the "Accept-Encoding" header sent when
+// response decompression is enabled, see [DecompressResponses].
+const acceptEncoding = "zstd, br, gzip, deflate"
+
+// maxCachedCompressedSize is the maximum size of a request body that will be
+// compressed ahead of time, larger bodies are compressed as they are sent.
+const maxCachedCompressedSize = 1024 * 1024
+
+// supported returns an error if the encoding is not supported.
+func (e Encoding) supported() error {
+	switch e {
+	case EncodingGzip, EncodingDeflate, EncodingBrotli, EncodingZstd:
+		return nil
+	default:
+		return fmt.Errorf("nxhttp: unsupported encoding '%s'", e)
+	}
+}
+
+// newWriter returns a new [io.WriteCloser] that compresses data written to it
+// into w using the encoding.
+func (e Encoding) newWriter(w io.Writer) (io.WriteCloser, error) {
+	switch e {
+	case EncodingGzip:
+		return gzip.NewWriter(w), nil
+	case EncodingDeflate:
+		return zlib.NewWriter(w), nil
+	case EncodingBrotli:
+		return brotli.NewWriter(w), nil
+	case EncodingZstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("nxhttp: unsupported encoding '%s'", e)
+	}
+}
+
+// newReader returns a new [io.ReadCloser] that decompresses data read from r
+// using the encoding.
+func (e Encoding) newReader(r io.Reader) (io.ReadCloser, error) {
+	switch e {
+	case EncodingGzip:
+		return gzip.NewReader(r)
+	case EncodingDeflate:
+		return zlib.NewReader(r)
+	case EncodingBrotli:
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case EncodingZstd:
+		// Avoid spawning additional goroutines for each response.
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("nxhttp: unsupported encoding '%s'", e)
+	}
+}
+
+// decompress replaces the body of res with one that decompresses it according
+// to the "Content-Encoding" header, if it uses a supported encoding. The
+// decompressed body is limited to maxSize bytes, unless maxSize is 0.
+//
+// Like [http.Transport], the "Content-Encoding" and "Content-Length" headers
+// are removed from the response if it is decompressed.
+func decompress(res *Response, maxSize int64) {
+	enc := Encoding(strings.ToLower(strings.TrimSpace(res.GetHeader(httpheader.ContentEncoding))))
+	if enc.supported() != nil {
+		return
+	}
+	if res.Body == nil || res.Body == http.NoBody {
+		return
+	}
+
+	var body io.ReadCloser = &decodingReadCloser{ReadCloser: res.Body, encoding: enc}
+	if maxSize > 0 {
+		body = newLimitedReadCloser(body, maxSize)
+	}
+	res.Body = body
+	httpheader.Del(res.Header, httpheader.ContentEncoding)
+	httpheader.Del(res.Header, httpheader.ContentLength)
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+// decodingReadCloser decompresses the [io.ReadCloser] it wraps.
+//
+// The decompressor is only created on the first call to Read, as most
+// decompressors read from the underlying reader as soon as they are created.
+type decodingReadCloser struct {
+	io.ReadCloser
+	encoding Encoding
+
+	// r is the decompressor, if it has been created.
+	r io.ReadCloser
+	// err from creating the decompressor.
+	err error
+}
+
+// Read satisfies [io.Reader].
+func (r *decodingReadCloser) Read(p []byte) (int, error) {
+	if r.r == nil && r.err == nil {
+		r.r, r.err = r.encoding.newReader(r.ReadCloser)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.r.Read(p)
+}
+
+// Close satisfies [io.Closer].
+func (r *decodingReadCloser) Close() error {
+	if r.r != nil {
+		_ = r.r.Close()
+	}
+	return r.ReadCloser.Close()
+}
+
+// compressor compresses request bodies, see [CompressBody].
+type compressor struct {
+	encoding Encoding
+	minSize  int64
+}
+
+// compress returns a shallow copy of req with its body compressed, or req
+// itself if the body should not be compressed.
+func (c *compressor) compress(req *Request) (*Request, error) {
+	// Avoid compressing requests without a body, with a body smaller than the
+	// minimum size, or that are already encoded.
+	if req.body == nil || (req.ContentLength >= 0 && req.ContentLength < c.minSize) {
+		return req, nil
+	}
+	if httpheader.Get(req.Header, httpheader.ContentEncoding) != "" {
+		return req, nil
+	}
+
+	// Ensure the encoding is supported before doing anything else.
+	if err := c.encoding.supported(); err != nil {
+		return nil, err
+	}
+
+	req = req.clone()
+	req.SetHeader(httpheader.ContentEncoding, string(c.encoding))
+
+	// Small bodies of a known size are compressed once ahead of time, so the
+	// length of the compressed body is known and sent as the ContentLength,
+	// and every attempt can re-use the compressed data.
+	if req.ContentLength >= 0 && req.ContentLength <= maxCachedCompressedSize {
+		var b bytes.Buffer
+		if err := c.write(&b, req.body); err != nil {
+			return nil, fmt.Errorf("nxhttp: failed to compress body: %w", err)
+		}
+		fn, n, err := bodyFuncFromReadSeekerSize(bytes.NewReader(b.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		req.setBodyFunc(fn, n)
+		return req, nil
+	}
+
+	// Otherwise, compress the body as it is being sent on each attempt. As
+	// the length of the compressed body is unknown, the body will be sent
+	// using chunked encoding.
+	p := &compressPipe{c: c, fn: req.body}
+	req.setBodyFunc(p.open, -1)
+	return req, nil
+}
+
+// compressPipe compresses a body as it is being sent, see [compressor.compress].
+type compressPipe struct {
+	c  *compressor
+	fn BodyFunc
+
+	mu sync.Mutex
+	// pr is the reader of the most recently opened pipe.
+	pr *io.PipeReader
+	// done is closed once the goroutine writing to pr returns.
+	done chan struct{}
+}
+
+// open returns a new reader for the compressed body.
+//
+// The writing goroutine of a previous attempt may still be reading the
+// original body, so it is stopped and waited for before the body is opened
+// again.
+func (p *compressPipe) open() (io.ReadCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pr != nil {
+		_ = p.pr.Close()
+		<-p.done
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// If the request fails, the transport will close the reader
+		// causing any writes to fail, so this will never leak.
+		_ = pw.CloseWithError(p.c.write(pw, p.fn))
+	}()
+	p.pr, p.done = pr, done
+	return pr, nil
+}
+
+// write writes the compressed body opened by fn to w.
+func (c *compressor) write(w io.Writer, fn BodyFunc) error {
+	body, err := fn()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	cw, err := c.encoding.newWriter(w)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(cw, body); err != nil {
+		_ = cw.Close()
+		return err
+	}
+	return cw.Close()
+}
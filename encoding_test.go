@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/matthewpi/nxhttp"
+)
+
+// decodeBody returns a reader that decodes r according to the given
+// "Content-Encoding".
+func decodeBody(enc string, r io.Reader) (io.Reader, error) {
+	switch enc {
+	case "":
+		return r, nil
+	case "gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return zlib.NewReader(r)
+	case "br":
+		return brotli.NewReader(r), nil
+	case "zstd":
+		return zstd.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unexpected encoding %q", enc)
+	}
+}
+
+func TestCompressBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Read the entire body before writing the response, otherwise the
+		// server may close the request body early.
+		body, err := decodeBody(r.Header.Get("Content-Encoding"), r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b, err := io.ReadAll(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Content-Encoding", r.Header.Get("Content-Encoding"))
+		w.Header().Set("X-Content-Length", r.Header.Get("Content-Length"))
+		_, _ = w.Write(b)
+	}))
+	defer ts.Close()
+	c := nxhttp.FromClient(ts.Client())
+
+	small := strings.Repeat("a", 64)
+	large := strings.Repeat("nxhttp", 512*1024)
+	for _, tc := range []struct {
+		name    string
+		enc     nxhttp.Encoding
+		body    string
+		encoded bool
+		chunked bool
+	}{
+		{"below minimum size", nxhttp.EncodingGzip, small[:8], false, false},
+		{"gzip", nxhttp.EncodingGzip, small, true, false},
+		{"deflate", nxhttp.EncodingDeflate, small, true, false},
+		{"br", nxhttp.EncodingBrotli, small, true, false},
+		{"zstd", nxhttp.EncodingZstd, small, true, false},
+		{"large zstd", nxhttp.EncodingZstd, large, true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL, tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := c.Do(req, nxhttp.CompressBody(tc.enc, 16))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Close()
+
+			b, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, []byte(tc.body)) {
+				t.Error("decompressed body does not match")
+			}
+			if got := res.Header.Get("X-Content-Encoding") != ""; got != tc.encoded {
+				t.Errorf("expected encoded to be %t, but got %t", tc.encoded, got)
+			}
+			if got := res.Header.Get("X-Content-Length") == ""; got != tc.chunked {
+				t.Errorf("expected chunked to be %t, but got %t", tc.chunked, got)
+			}
+			if req.Header.Get("Content-Encoding") != "" {
+				t.Error("original request should not be modified")
+			}
+		})
+	}
+}
+
+func TestDecompressResponses(t *testing.T) {
+	body := strings.Repeat("nxhttp", 1024)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "zstd, br, gzip, deflate" {
+			t.Errorf("unexpected Accept-Encoding header: %q", r.Header.Get("Accept-Encoding"))
+		}
+
+		enc := r.URL.Query().Get("encoding")
+		var b bytes.Buffer
+		var zw io.WriteCloser
+		switch enc {
+		case "gzip":
+			zw = gzip.NewWriter(&b)
+		case "deflate":
+			zw = zlib.NewWriter(&b)
+		case "br":
+			zw = brotli.NewWriter(&b)
+		case "zstd":
+			zw, _ = zstd.NewWriter(&b)
+		}
+		_, _ = io.WriteString(zw, body)
+		_ = zw.Close()
+
+		w.Header().Set("Content-Encoding", enc)
+		_, _ = w.Write(b.Bytes())
+	}))
+	defer ts.Close()
+
+	for _, enc := range []string{"gzip", "deflate", "br", "zstd"} {
+		t.Run(enc, func(t *testing.T) {
+			for _, tc := range []struct {
+				limit int64
+				err   bool
+			}{
+				{0, false},
+				{int64(len(body)), false},
+				{int64(len(body)) - 1, true},
+			} {
+				c := nxhttp.FromClient(ts.Client(), nxhttp.DecompressResponses(tc.limit))
+				req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL+"?encoding="+enc, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				res, err := c.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Header.Get("Content-Encoding") != "" {
+					t.Error("expected Content-Encoding header to be removed")
+				}
+
+				b, err := io.ReadAll(res.Body)
+				_ = res.Close()
+				var tErr nxhttp.BodyTooLargeError
+				if tc.err {
+					if !errors.As(err, &tErr) {
+						t.Errorf("expected a BodyTooLargeError, but got %v", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != body {
+					t.Error("decompressed body does not match")
+				}
+			}
+		})
+	}
+}
+
+func TestCompressBody_Retry(t *testing.T) {
+	ts, n := newRetryTestServer(t, http.StatusServiceUnavailable)
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+
+	// The body is opened again for every attempt, which must not happen while
+	// the body opened for a previous attempt is still being read.
+	var open atomic.Int32
+	body := nxhttp.ReadOpenerFor(func() (io.ReadCloser, error) {
+		if open.Add(1) != 1 {
+			t.Error("body was opened while a previous body was still open")
+		}
+		return &slowReadCloser{
+			r:     io.LimitReader(rand.Reader, 64*1024*1024),
+			close: func() { open.Add(-1) },
+		}, nil
+	}, -1)
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPut, ts.URL, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.Do(req, nxhttp.CompressBody(nxhttp.EncodingGzip, 0)); err == nil {
+		_ = res.Close()
+	}
+	if got := n.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, but got %d", got)
+	}
+}
+
+// slowReadCloser is an [io.ReadCloser] that sleeps before every read and
+// calls close when it is closed.
+type slowReadCloser struct {
+	r     io.Reader
+	close func()
+}
+
+func (r *slowReadCloser) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return r.r.Read(p)
+}
+
+func (r *slowReadCloser) Close() error {
+	r.close()
+	return nil
+}
@@ -6,11 +6,14 @@ package nxhttp
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/matthewpi/nxhttp/httpheader"
 )
@@ -33,6 +36,69 @@ func isTimeout(err error) bool {
 	return errors.As(err, &tErr) && tErr.Timeout()
 }
 
+// BodyTooLargeError is returned when reading a response body that exceeds a
+// configured size limit.
+type BodyTooLargeError struct {
+	// Limit is the maximum number of bytes that were allowed to be read.
+	Limit int64
+}
+
+var (
+	_ error          = BodyTooLargeError{}
+	_ slog.LogValuer = BodyTooLargeError{}
+)
+
+// Error returns an error message and satisfies the [error] interface.
+func (e BodyTooLargeError) Error() string {
+	return fmt.Sprintf("nxhttp: response body exceeds the limit of %d bytes", e.Limit)
+}
+
+// LogValue returns an [slog.Value] and satisfies the [slog.LogValuer] interface.
+func (e BodyTooLargeError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", e.Error()),
+		slog.Int64("limit", e.Limit),
+	)
+}
+
+// BodyTimeoutError is returned when reading a response body takes longer than
+// a configured timeout.
+type BodyTimeoutError struct {
+	// Duration of the timeout that was reached.
+	Duration time.Duration
+	// Idle indicates the idle timeout was reached, meaning no data was
+	// received for the Duration, rather than the timeout for reading the
+	// entire body.
+	Idle bool
+}
+
+var (
+	_ error          = BodyTimeoutError{}
+	_ slog.LogValuer = BodyTimeoutError{}
+)
+
+// Error returns an error message and satisfies the [error] interface.
+func (e BodyTimeoutError) Error() string {
+	if e.Idle {
+		return fmt.Sprintf("nxhttp: no response body data received for %s", e.Duration)
+	}
+	return fmt.Sprintf("nxhttp: reading response body took longer than %s", e.Duration)
+}
+
+// LogValue returns an [slog.Value] and satisfies the [slog.LogValuer] interface.
+func (e BodyTimeoutError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", e.Error()),
+		slog.Duration("duration", e.Duration),
+		slog.Bool("idle", e.Idle),
+	)
+}
+
+// Timeout reports whether the error is a timeout, which is always true.
+func (e BodyTimeoutError) Timeout() bool {
+	return true
+}
+
 // ContentError indicates an HTTP response contained an unexpected `Content-*`
 // header.
 type ContentError struct {
@@ -106,6 +172,132 @@ func (e RequestError) Unwrap() error {
 	return e.err
 }
 
+// RetryAfterError indicates an HTTP response contained a malformed
+// "Retry-After" header (or one of its alternatives, such as "RateLimit-Reset").
+type RetryAfterError struct {
+	// Header that contained the malformed value.
+	Header httpheader.Key
+	// Value of the header in the response.
+	Value string
+
+	// err from parsing the header.
+	err error
+}
+
+var (
+	_ error          = RetryAfterError{}
+	_ slog.LogValuer = RetryAfterError{}
+)
+
+// Error returns an error message and satisfies the [error] interface.
+func (e RetryAfterError) Error() string {
+	return fmt.Sprintf("nxhttp: malformed '%s' header value '%s' in response: %v", e.Header, e.Value, e.err)
+}
+
+// LogValue returns an [slog.Value] and satisfies the [slog.LogValuer] interface.
+func (e RetryAfterError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("header", string(e.Header)),
+		slog.String("value", e.Value),
+		slog.Any("err", e.err),
+	)
+}
+
+// Unwrap returns the underlying [error] that caused the [RetryAfterError].
+func (e RetryAfterError) Unwrap() error {
+	return e.err
+}
+
+// RetryExhaustedError is returned by [Client.Do] if the request would have been
+// retried, but no attempts remain.
+type RetryExhaustedError struct {
+	// Attempts that were made, in order.
+	Attempts []Attempt
+
+	// Err from the final attempt. If the final attempt received a response,
+	// Err is a [StatusError] containing the start of the response body.
+	Err error
+	// ContextErr is the error of the request's context if it was done before
+	// the next attempt could be made, such as [context.Canceled].
+	ContextErr error
+}
+
+var (
+	_ error          = RetryExhaustedError{}
+	_ slog.LogValuer = RetryExhaustedError{}
+)
+
+// Error returns an error message and satisfies the [error] interface.
+func (e RetryExhaustedError) Error() string {
+	attempts := "attempts"
+	if len(e.Attempts) == 1 {
+		attempts = "attempt"
+	}
+	msg := fmt.Sprintf("nxhttp: giving up after %d %s: %s", len(e.Attempts), attempts, strings.Join(e.summary(), ", "))
+	if e.ContextErr != nil {
+		msg += " (" + e.ContextErr.Error() + ")"
+	}
+	return msg
+}
+
+// LogValue returns an [slog.Value] and satisfies the [slog.LogValuer] interface.
+func (e RetryExhaustedError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", e.Error()),
+		slog.Int("attempts", len(e.Attempts)),
+		slog.Any("err", e.Err),
+		slog.Any("context_err", e.ContextErr),
+	)
+}
+
+// Unwrap returns the errors from every attempt, starting with [Err] and
+// [ContextErr].
+//
+// This allows [errors.Is] and [errors.As] to match the error from any of the
+// attempts, such as a [RequestError] or [RetryAfterError], or the error of the
+// request's context, such as [context.Canceled].
+func (e RetryExhaustedError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts)+2)
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	if e.ContextErr != nil {
+		errs = append(errs, e.ContextErr)
+	}
+	for i, a := range e.Attempts {
+		// Avoid including the error from the final attempt twice.
+		if a.Err == nil || (i == len(e.Attempts)-1 && a.StatusCode == 0) {
+			continue
+		}
+		errs = append(errs, a.Err)
+	}
+	return errs
+}
+
+// summary returns a short description of the outcome of each attempt.
+func (e RetryExhaustedError) summary() []string {
+	s := make([]string, len(e.Attempts))
+	for i, a := range e.Attempts {
+		switch {
+		case a.StatusCode != 0:
+			s[i] = strconv.Itoa(a.StatusCode)
+		case a.Err == nil:
+			s[i] = "unknown error"
+		case isTimeout(a.Err):
+			s[i] = "timeout"
+		default:
+			err := a.Err
+			// Avoid the "nxhttp request failed" prefix being repeated for
+			// every attempt.
+			if rErr, ok := err.(RequestError); ok {
+				err = rErr.err
+			}
+			s[i] = err.Error()
+		}
+	}
+	return s
+}
+
 // StatusError indicates an HTTP request failure with a status code from a
 // remote HTTP server.
 type StatusError struct {
@@ -116,9 +308,10 @@ type StatusError struct {
 	// error.
 	StatusCode int
 
-	// Expected is the status code we expected to see in the [Response] but
-	// we got [StatusCode] instead.
-	Expected int
+	// Expected is the set of status codes we expected to see in the
+	// [Response] but we got [StatusCode] instead. If empty, any successful
+	// (2xx) status code was expected.
+	Expected []int
 }
 
 var (
@@ -127,16 +320,20 @@ var (
 )
 
 // NewStatusError returns a new [StatusError].
-func NewStatusError(res *Response, expected int) StatusError {
+//
+// If res is not nil, the start of the response body is read into
+// [StatusError.Data] and the response body is closed.
+func NewStatusError(res *Response, expected ...int) StatusError {
 	e := StatusError{Expected: expected}
 	if res == nil {
 		return e
 	}
 	e.StatusCode = res.StatusCode
 	if res.Body != nil {
-		if b, err := io.ReadAll(http.MaxBytesReader(nil, res.Body, 4*1024)); err == nil {
-			e.Data = bytes.TrimSpace(b)
-		}
+		// Only the start of the body is kept, even if reading the rest of it
+		// fails.
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 4*1024))
+		e.Data = bytes.TrimSpace(b)
 		_ = res.Body.Close()
 	}
 	return e
@@ -156,7 +353,16 @@ func AsStatusError(err error) (StatusError, bool) {
 
 // Error returns an error message and satisfies the [error] interface.
 func (e StatusError) Error() string {
-	return fmt.Sprintf("nxhttp: expected %d status code, but got %d (%q)", e.Expected, e.StatusCode, e.Data)
+	// If only one status code was expected, print it in a nicer format
+	// instead of as a slice with a single item.
+	switch len(e.Expected) {
+	case 0:
+		return fmt.Sprintf("nxhttp: unexpected %d status code (%q)", e.StatusCode, e.Data)
+	case 1:
+		return fmt.Sprintf("nxhttp: expected %d status code, but got %d (%q)", e.Expected[0], e.StatusCode, e.Data)
+	default:
+		return fmt.Sprintf("nxhttp: expected one of %v status codes, but got %d (%q)", e.Expected, e.StatusCode, e.Data)
+	}
 }
 
 // LogValue returns an [slog.Value] and satisfies the [slog.LogValuer] interface.
@@ -165,7 +371,109 @@ func (e StatusError) LogValue() slog.Value {
 		slog.String("message", e.Error()),
 		slog.GroupAttrs("status_code",
 			slog.Int("got", e.StatusCode),
-			slog.Int("expected", e.Expected),
+			slog.Any("expected", e.Expected),
 		),
 	)
 }
+
+// DigestError is returned when the body of a response doesn't match the
+// digest sent by the server in its "Content-Digest" or "Repr-Digest" header.
+type DigestError struct {
+	// Header containing the expected digest.
+	Header httpheader.Key
+	// Algorithm of the digest.
+	Algorithm DigestAlgorithm
+	// Expected digest, as sent by the server.
+	Expected []byte
+	// Actual digest of the body that was received.
+	Actual []byte
+}
+
+var (
+	_ error          = DigestError{}
+	_ slog.LogValuer = DigestError{}
+)
+
+// Error returns an error message and satisfies the [error] interface.
+func (e DigestError) Error() string {
+	return fmt.Sprintf("nxhttp: response body does not match %s (%s)", e.Header, e.Algorithm)
+}
+
+// LogValue returns an [slog.Value] and satisfies the [slog.LogValuer] interface.
+func (e DigestError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", e.Error()),
+		slog.String("header", string(e.Header)),
+		slog.String("algorithm", string(e.Algorithm)),
+		slog.String("expected", base64.StdEncoding.EncodeToString(e.Expected)),
+		slog.String("actual", base64.StdEncoding.EncodeToString(e.Actual)),
+	)
+}
+
+// OAuth2Error is returned when an OAuth2 token endpoint responds with an
+// error (RFC 6749, Section 5.2).
+type OAuth2Error struct {
+	// StatusCode of the response.
+	StatusCode int `json:"-"`
+	// Code is the error code, such as "invalid_client".
+	Code string `json:"error"`
+	// Description is a human-readable description of the error, if any.
+	Description string `json:"error_description,omitempty"`
+	// URI of a web page with information about the error, if any.
+	URI string `json:"error_uri,omitempty"`
+}
+
+var (
+	_ error          = OAuth2Error{}
+	_ slog.LogValuer = OAuth2Error{}
+)
+
+// Error returns an error message and satisfies the [error] interface.
+func (e OAuth2Error) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("nxhttp: oauth2: %s: %s", e.Code, e.Description)
+	}
+	return "nxhttp: oauth2: " + e.Code
+}
+
+// LogValue returns an [slog.Value] and satisfies the [slog.LogValuer] interface.
+func (e OAuth2Error) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", e.Error()),
+		slog.Int("status_code", e.StatusCode),
+		slog.String("code", e.Code),
+		slog.String("description", e.Description),
+		slog.String("uri", e.URI),
+	)
+}
+
+// SignatureError is returned when the HTTP message signature of a response
+// could not be verified.
+type SignatureError struct {
+	// Label of the signature, if known.
+	Label string
+	// Reason the signature could not be verified.
+	Reason string
+}
+
+var (
+	_ error          = SignatureError{}
+	_ slog.LogValuer = SignatureError{}
+)
+
+// Error returns an error message and satisfies the [error] interface.
+func (e SignatureError) Error() string {
+	if e.Label != "" {
+		return fmt.Sprintf("nxhttp: failed to verify signature '%s': %s", e.Label, e.Reason)
+	}
+	return "nxhttp: failed to verify signature: " + e.Reason
+}
+
+// LogValue returns an [slog.Value] and satisfies the [slog.LogValuer] interface.
+func (e SignatureError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("message", e.Error()),
+		slog.String("label", e.Label),
+		slog.String("reason", e.Reason),
+	)
+}
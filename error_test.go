@@ -4,8 +4,12 @@
 package nxhttp_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/matthewpi/nxhttp"
@@ -16,7 +20,7 @@ func TestStatusError(t *testing.T) {
 	err = nxhttp.NewStatusError(nil, http.StatusOK)
 	if sErr, ok := nxhttp.AsStatusError(err); !ok {
 		t.Error("return result of nxhttp.NewStatusError does not work with nxhttp.AsStatusError")
-	} else if sErr.Expected != http.StatusOK {
+	} else if !slices.Equal(sErr.Expected, []int{http.StatusOK}) {
 		t.Error("nxhttp.AsStatusError returned a different nxhttp.StatusError")
 	}
 
@@ -26,7 +30,64 @@ func TestStatusError(t *testing.T) {
 
 	if sErr, ok := nxhttp.AsStatusError(err); !ok {
 		t.Error("wrapped result of nxhttp.NewStatusError does not work with nxhttp.AsStatusError")
-	} else if sErr.Expected != http.StatusOK {
+	} else if !slices.Equal(sErr.Expected, []int{http.StatusOK}) {
 		t.Error("nxhttp.AsStatusError returned a different nxhttp.StatusError")
 	}
 }
+
+func TestExpectStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("  queued\n"))
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client())
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := c.Do(req, nxhttp.ExpectStatus(http.StatusOK, http.StatusAccepted))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = res.Close()
+
+	_, err = c.Do(req, nxhttp.ExpectStatus(http.StatusOK, http.StatusNoContent))
+	sErr, ok := nxhttp.AsStatusError(err)
+	if !ok {
+		t.Fatalf("expected a StatusError, but got %v", err)
+	}
+	if sErr.StatusCode != http.StatusAccepted {
+		t.Errorf("expected status code %d, but got %d", http.StatusAccepted, sErr.StatusCode)
+	}
+	if !slices.Equal(sErr.Expected, []int{http.StatusOK, http.StatusNoContent}) {
+		t.Errorf("unexpected expected status codes: %v", sErr.Expected)
+	}
+	if string(sErr.Data) != "queued" {
+		t.Errorf("expected data to be %q, but got %q", "queued", sErr.Data)
+	}
+}
+
+func TestStatusError_LargeBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(strings.Repeat("a", 16*1024)))
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client())
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Do(req, nxhttp.ExpectStatus(http.StatusOK))
+	sErr, ok := nxhttp.AsStatusError(err)
+	if !ok {
+		t.Fatalf("expected a StatusError, but got %v", err)
+	}
+	if len(sErr.Data) != 4*1024 {
+		t.Errorf("expected the first 4 KiB of the body, but got %d bytes", len(sErr.Data))
+	}
+}
@@ -2,4 +2,8 @@ module github.com/matthewpi/nxhttp
 
 go 1.25
 
-require github.com/matthewpi/nxretry v0.0.0-20260111234625-a11347f1fd1d
+require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/klauspost/compress v1.18.0
+	github.com/matthewpi/nxretry v0.0.0-20260111234625-a11347f1fd1d
+)
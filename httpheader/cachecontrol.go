@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package httpheader
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheDirectives represents the directives of an HTTP [Cache-Control] header.
+//
+// Durations are negative if the directive is not present.
+//
+// [Cache-Control]: https://www.rfc-editor.org/rfc/rfc9111#section-5.2
+type CacheDirectives struct {
+	// MaxAge is the "max-age" directive.
+	MaxAge time.Duration
+	// MaxStale is the "max-stale" request directive. If the directive is
+	// present without a value, any amount of staleness is accepted, which is
+	// represented by the maximum [time.Duration].
+	MaxStale time.Duration
+	// MinFresh is the "min-fresh" request directive.
+	MinFresh time.Duration
+	// StaleWhileRevalidate is the "stale-while-revalidate" directive
+	// (RFC 5861).
+	StaleWhileRevalidate time.Duration
+	// StaleIfError is the "stale-if-error" directive (RFC 5861).
+	StaleIfError time.Duration
+
+	// NoCache is the "no-cache" directive.
+	NoCache bool
+	// NoStore is the "no-store" directive.
+	NoStore bool
+	// MustRevalidate is the "must-revalidate" response directive.
+	MustRevalidate bool
+	// Private is the "private" response directive.
+	Private bool
+	// Public is the "public" response directive.
+	Public bool
+	// Immutable is the "immutable" response directive (RFC 8246).
+	Immutable bool
+	// OnlyIfCached is the "only-if-cached" request directive.
+	OnlyIfCached bool
+}
+
+// ParseCacheControl parses the values of an HTTP [Cache-Control] header.
+//
+// Unknown directives are ignored, as are directives with an invalid value,
+// as required by RFC 9111.
+//
+// [Cache-Control]: https://www.rfc-editor.org/rfc/rfc9111#section-5.2
+func ParseCacheControl(values ...string) CacheDirectives {
+	cc := CacheDirectives{
+		MaxAge:               -1,
+		MaxStale:             -1,
+		MinFresh:             -1,
+		StaleWhileRevalidate: -1,
+		StaleIfError:         -1,
+	}
+	for _, v := range values {
+		for directive := range strings.SplitSeq(v, ",") {
+			name, arg, hasArg := strings.Cut(strings.TrimSpace(directive), "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			arg = strings.Trim(strings.TrimSpace(arg), `"`)
+
+			switch name {
+			case "max-age":
+				cc.MaxAge = parseDeltaSeconds(arg, cc.MaxAge)
+			case "max-stale":
+				if hasArg {
+					cc.MaxStale = parseDeltaSeconds(arg, cc.MaxStale)
+				} else {
+					cc.MaxStale = maxDuration
+				}
+			case "min-fresh":
+				cc.MinFresh = parseDeltaSeconds(arg, cc.MinFresh)
+			case "stale-while-revalidate":
+				cc.StaleWhileRevalidate = parseDeltaSeconds(arg, cc.StaleWhileRevalidate)
+			case "stale-if-error":
+				cc.StaleIfError = parseDeltaSeconds(arg, cc.StaleIfError)
+			case "no-cache":
+				cc.NoCache = true
+			case "no-store":
+				cc.NoStore = true
+			case "must-revalidate", "proxy-revalidate":
+				cc.MustRevalidate = true
+			case "private":
+				cc.Private = true
+			case "public":
+				cc.Public = true
+			case "immutable":
+				cc.Immutable = true
+			case "only-if-cached":
+				cc.OnlyIfCached = true
+			}
+		}
+	}
+	return cc
+}
+
+// maxDuration is the maximum [time.Duration].
+const maxDuration = time.Duration(math.MaxInt64)
+
+// parseDeltaSeconds parses a delta-seconds value, returning fallback if the
+// value is invalid.
+func parseDeltaSeconds(v string, fallback time.Duration) time.Duration {
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		// Values that overflow are treated as the largest possible value.
+		if errors.Is(err, strconv.ErrRange) && i > 0 {
+			return maxDuration
+		}
+		return fallback
+	}
+	if i < 0 {
+		return fallback
+	}
+	// Avoid overflowing when converting to a duration.
+	if i > int64(maxDuration/time.Second) {
+		return maxDuration
+	}
+	return time.Duration(i) * time.Second
+}
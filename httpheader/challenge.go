@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package httpheader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Challenge is an authentication challenge from a [WWW-Authenticate] header.
+//
+// [WWW-Authenticate]: https://www.rfc-editor.org/rfc/rfc9110#section-11.6.1
+type Challenge struct {
+	// Scheme of the challenge, such as "Basic" or "Digest".
+	Scheme string
+	// Token68 of the challenge, if it uses one instead of parameters.
+	Token68 string
+	// Params of the challenge, keyed by their lower-case name.
+	Params map[string]string
+}
+
+// ParseChallenges parses the values of an HTTP [WWW-Authenticate] header into
+// the challenges they contain.
+//
+// [WWW-Authenticate]: https://www.rfc-editor.org/rfc/rfc9110#section-11.6.1
+func ParseChallenges(values ...string) ([]Challenge, error) {
+	var challenges []Challenge
+	for _, v := range values {
+		p := &challengeParser{s: v}
+		for {
+			p.skip(" \t,")
+			if p.done() {
+				break
+			}
+			c, err := p.challenge()
+			if err != nil {
+				return nil, fmt.Errorf("nxhttp: malformed WWW-Authenticate header '%s': %w", v, err)
+			}
+			challenges = append(challenges, c)
+		}
+	}
+	return challenges, nil
+}
+
+// challengeParser parses the challenges of a [WWW-Authenticate] header.
+//
+// [WWW-Authenticate]: https://www.rfc-editor.org/rfc/rfc9110#section-11.6.1
+type challengeParser struct {
+	s string
+	i int
+}
+
+// done reports whether the entire value was parsed.
+func (p *challengeParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+// peek returns the next byte, or 0 if the entire value was parsed.
+func (p *challengeParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+// skip skips any of the bytes in chars.
+func (p *challengeParser) skip(chars string) {
+	for !p.done() && strings.IndexByte(chars, p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// token parses a token, returning an empty string if there isn't one.
+func (p *challengeParser) token() string {
+	start := p.i
+	for !p.done() && isTokenChar(p.s[p.i]) {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// challenge parses a single challenge.
+func (p *challengeParser) challenge() (Challenge, error) {
+	c := Challenge{Scheme: p.token(), Params: map[string]string{}}
+	if c.Scheme == "" {
+		return c, fmt.Errorf("expected an auth-scheme at offset %d", p.i)
+	}
+	p.skip(" \t")
+
+	// The scheme may be followed by a token68 instead of parameters.
+	if t, ok := p.token68(); ok {
+		c.Token68 = t
+		return c, nil
+	}
+
+	for {
+		p.skip(" \t")
+		if p.done() {
+			return c, nil
+		}
+
+		// A comma either separates the parameters of this challenge, or
+		// starts the next challenge.
+		start := p.i
+		if p.peek() == ',' {
+			p.skip(" \t,")
+			if p.done() {
+				return c, nil
+			}
+		}
+		paramStart := p.i
+		name := p.token()
+		p.skip(" \t")
+		if name == "" || p.peek() != '=' {
+			if start != paramStart && name != "" {
+				// The token is the scheme of the next challenge.
+				p.i = paramStart
+				return c, nil
+			}
+			return c, fmt.Errorf("expected an auth-param at offset %d", paramStart)
+		}
+		p.i++
+		p.skip(" \t")
+
+		value, err := p.value()
+		if err != nil {
+			return c, err
+		}
+		c.Params[strings.ToLower(name)] = value
+	}
+}
+
+// token68 parses a token68 if there is one at the current position.
+func (p *challengeParser) token68() (string, bool) {
+	start := p.i
+	for !p.done() && (isTokenChar(p.s[p.i]) || p.s[p.i] == '/') {
+		p.i++
+	}
+	if p.i == start {
+		return "", false
+	}
+	p.skip("=")
+	end := p.i
+	p.skip(" \t")
+	if p.done() || p.peek() == ',' {
+		return p.s[start:end], true
+	}
+	p.i = start
+	return "", false
+}
+
+// value parses a token or quoted-string.
+func (p *challengeParser) value() (string, error) {
+	if p.peek() != '"' {
+		return p.token(), nil
+	}
+	p.i++
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.i]
+		p.i++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.done() {
+				return "", fmt.Errorf("unterminated quoted-string")
+			}
+			c = p.s[p.i]
+			p.i++
+		}
+		b.WriteByte(c)
+	}
+	return "", fmt.Errorf("unterminated quoted-string")
+}
+
+// isTokenChar reports whether c is a tchar (RFC 9110, Section 5.6.2).
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
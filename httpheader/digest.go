@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package httpheader
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Digest is a single digest from a [Content-Digest] or [Repr-Digest] header.
+//
+// [Content-Digest]: https://www.rfc-editor.org/rfc/rfc9530#section-2
+// [Repr-Digest]: https://www.rfc-editor.org/rfc/rfc9530#section-3
+type Digest struct {
+	// Algorithm used to calculate the digest, such as "sha-256".
+	Algorithm string
+	// Value of the digest.
+	Value []byte
+}
+
+// ParseDigest parses an HTTP [Content-Digest] or [Repr-Digest] header into
+// the [Digest] it contains.
+//
+// The header is a Structured Field Dictionary where each key is the algorithm
+// and each value is a Byte Sequence containing the digest. Any parameters on
+// a value are ignored, as are members that aren't a valid digest, such as
+// legacy algorithms using an Integer value. An error is only returned if the
+// header doesn't contain any valid digests.
+//
+// [Content-Digest]: https://www.rfc-editor.org/rfc/rfc9530#section-2
+// [Repr-Digest]: https://www.rfc-editor.org/rfc/rfc9530#section-3
+func ParseDigest(v string) ([]Digest, error) {
+	var digests []Digest
+	for member := range strings.SplitSeq(v, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+
+		alg, value, ok := strings.Cut(member, "=")
+		if !ok || !isKey(alg) {
+			continue
+		}
+		b, err := parseByteSequence(value)
+		if err != nil {
+			continue
+		}
+		digests = append(digests, Digest{Algorithm: alg, Value: b})
+	}
+	if len(digests) == 0 && strings.TrimSpace(v) != "" {
+		return nil, fmt.Errorf("nxhttp: no valid digests in '%s'", v)
+	}
+	return digests, nil
+}
+
+// FormatDigest formats the given digests as the value of an HTTP
+// [Content-Digest] or [Repr-Digest] header.
+//
+// [Content-Digest]: https://www.rfc-editor.org/rfc/rfc9530#section-2
+// [Repr-Digest]: https://www.rfc-editor.org/rfc/rfc9530#section-3
+func FormatDigest(digests ...Digest) string {
+	var b strings.Builder
+	for i, d := range digests {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(d.Algorithm)
+		b.WriteString("=:")
+		b.WriteString(base64.StdEncoding.EncodeToString(d.Value))
+		b.WriteByte(':')
+	}
+	return b.String()
+}
+
+// isKey reports whether s is a valid Structured Field key.
+//
+// See https://www.rfc-editor.org/rfc/rfc9651#section-3.1.2
+func isKey(s string) bool {
+	if s == "" || (s[0] != '*' && (s[0] < 'a' || s[0] > 'z')) {
+		return false
+	}
+	for _, c := range []byte(s[1:]) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '_', c == '-', c == '.', c == '*':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseByteSequence parses a Structured Field Byte Sequence, ignoring any
+// parameters.
+//
+// See https://www.rfc-editor.org/rfc/rfc9651#section-3.3.5
+func parseByteSequence(v string) ([]byte, error) {
+	v, _, _ = strings.Cut(v, ";")
+	if len(v) < 2 || v[0] != ':' || v[len(v)-1] != ':' {
+		return nil, fmt.Errorf("expected a byte sequence")
+	}
+	return base64.StdEncoding.DecodeString(v[1 : len(v)-1])
+}
@@ -207,6 +207,10 @@ const (
 	// [Keep-Alive]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Keep-Alive
 	KeepAlive Key = "Keep-Alive"
 
+	// LastEventID is the HTTP [Last-Event-ID] header.
+	// [Last-Event-ID]: https://html.spec.whatwg.org/multipage/server-sent-events.html#the-last-event-id-header
+	LastEventID Key = "Last-Event-Id" // lower-case d is intended, do not change it.
+
 	// LastModified is the HTTP [Last-Modified] header.
 	// [Last-Modified]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Last-Modified
 	LastModified Key = "Last-Modified"
@@ -227,6 +231,10 @@ const (
 	// [Range]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Range
 	Range Key = "Range"
 
+	// RateLimitReset is the HTTP [RateLimit-Reset] header.
+	// [RateLimit-Reset]: https://datatracker.ietf.org/doc/html/draft-ietf-httpapi-ratelimit-headers-07#section-3.3
+	RateLimitReset Key = "Ratelimit-Reset" // lower-case l is intended, do not change it.
+
 	// ReprDigest is the HTTP [Repr-Digest] header.
 	// [Repr-Digest]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Repr-Digest
 	ReprDigest Key = "Repr-Digest"
@@ -243,6 +251,14 @@ const (
 	// [Set-Cookie]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/Set-Cookie
 	SetCookie Key = "Set-Cookie"
 
+	// Signature is the HTTP [Signature] header.
+	// [Signature]: https://www.rfc-editor.org/rfc/rfc9421#section-4.2
+	Signature Key = "Signature"
+
+	// SignatureInput is the HTTP [Signature-Input] header.
+	// [Signature-Input]: https://www.rfc-editor.org/rfc/rfc9421#section-4.1
+	SignatureInput Key = "Signature-Input"
+
 	// TE is the HTTP [TE] header.
 	// [TE]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/TE
 	TE Key = "Te" // lower-case e is intended, do not change it.
@@ -274,6 +290,27 @@ const (
 	// WWWAuthenticate is the HTTP [WWW-Authenticate] header.
 	// [WWW-Authenticate]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Reference/Headers/WWW-Authenticate
 	WWWAuthenticate Key = "Www-Authenticate" // lower-case "w"s are intended, do not change it.
+
+	// XAmzContentSHA256 is the non-standard HTTP [X-Amz-Content-Sha256] header.
+	// [X-Amz-Content-Sha256]: https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+	XAmzContentSHA256 Key = "X-Amz-Content-Sha256" // lower-case "ha" is intended, do not change it.
+
+	// XAmzDate is the non-standard HTTP [X-Amz-Date] header.
+	// [X-Amz-Date]: https://docs.aws.amazon.com/IAM/latest/UserGuide/signing-elements.html
+	XAmzDate Key = "X-Amz-Date"
+
+	// XAmzDecodedContentLength is the non-standard HTTP
+	// [X-Amz-Decoded-Content-Length] header.
+	// [X-Amz-Decoded-Content-Length]: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+	XAmzDecodedContentLength Key = "X-Amz-Decoded-Content-Length"
+
+	// XAmzSecurityToken is the non-standard HTTP [X-Amz-Security-Token] header.
+	// [X-Amz-Security-Token]: https://docs.aws.amazon.com/IAM/latest/UserGuide/signing-elements.html
+	XAmzSecurityToken Key = "X-Amz-Security-Token"
+
+	// XRateLimitReset is the non-standard HTTP [X-RateLimit-Reset] header.
+	// [X-RateLimit-Reset]: https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api#checking-the-status-of-your-rate-limit
+	XRateLimitReset Key = "X-Ratelimit-Reset" // lower-case l is intended, do not change it.
 )
 
 func init() {
@@ -316,15 +353,19 @@ func init() {
 		IfRange,
 		IfUnmodifiedSince,
 		KeepAlive,
+		LastEventID,
 		LastModified,
 		Link,
 		Location,
 		Origin,
 		Range,
+		RateLimitReset,
 		ReprDigest,
 		RetryAfter,
 		Server,
 		SetCookie,
+		Signature,
+		SignatureInput,
 		TE,
 		Trailer,
 		TransferEncoding,
@@ -333,6 +374,11 @@ func init() {
 		Vary,
 		Via,
 		WWWAuthenticate,
+		XAmzContentSHA256,
+		XAmzDate,
+		XAmzDecodedContentLength,
+		XAmzSecurityToken,
+		XRateLimitReset,
 	} {
 		if expected := Canonicalize(string(got)); got != expected {
 			panic("nxhttp/httpheader: header is not properly canonicalized (got: \"" + got + "\", expected: \"" + expected + "\")")
@@ -13,7 +13,7 @@ import (
 // ParseRetryAfter parses an HTTP [Retry-After] header into a [time.Duration].
 //
 // The value of the header could be either an HTTP Date (see [http.ParseTime])
-// or a number of seconds.
+// or a number of seconds. A date in the past results in a zero duration.
 //
 // [Retry-After]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Retry-After
 func ParseRetryAfter(v string) (time.Duration, error) {
@@ -42,14 +42,49 @@ func ParseRetryAfter(v string) (time.Duration, error) {
 
 	// We want to return a [time.Duration], not a [time.Time] to the caller, so
 	// subtract the current time from the Retry-After time.
-	until := time.Until(t)
+	//
+	// A date in the past is most likely the result of clock skew between us
+	// and the server, so retry immediately rather than treating the header as
+	// malformed.
+	return max(time.Until(t), 0), nil
+}
+
+// ParseRateLimitReset parses an HTTP [RateLimit-Reset] or non-standard
+// [X-RateLimit-Reset] header into a [time.Duration].
+//
+// The value of the header is expected to be a number of seconds. As some
+// services send a Unix timestamp in the X-RateLimit-Reset header instead,
+// values large enough to be a timestamp are treated as one, a timestamp in the
+// past results in a zero duration.
+//
+// [RateLimit-Reset]: https://datatracker.ietf.org/doc/html/draft-ietf-httpapi-ratelimit-headers-07#section-3.3
+// [X-RateLimit-Reset]: https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api#checking-the-status-of-your-rate-limit
+func ParseRateLimitReset(v string) (time.Duration, error) {
+	// Fast-path, empty string.
+	if v == "" {
+		return 0, nil
+	}
 
-	// Ensure the duration isn't negative as most callers would not be expecting
-	// a negative value.
-	if until < 0 {
-		return 0, fmt.Errorf("nxhttp: Retry-After date is in the past (%s)", t)
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("nxhttp: failed to parse rate limit reset header from response: %w", err)
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("nxhttp: got negative rate limit reset value in response (%d)", i)
 	}
 
-	// Return the calculated [time.Duration] from the date.
-	return until, nil
+	// A delay of this many seconds would be over 31 years, so assume the
+	// value is a Unix timestamp.
+	if i < unixTimestampThreshold {
+		return time.Duration(i) * time.Second, nil
+	}
+
+	// A reset time in the past is most likely the result of clock skew
+	// between us and the server, so retry immediately rather than treating
+	// the header as malformed.
+	return max(time.Until(time.Unix(i, 0)), 0), nil
 }
+
+// unixTimestampThreshold is the value (in seconds) above which a rate limit
+// reset header is treated as a Unix timestamp rather than a delay.
+const unixTimestampThreshold = 1_000_000_000
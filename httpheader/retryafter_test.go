@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package httpheader_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		v        string
+		min, max time.Duration
+		err      bool
+	}{
+		{v: ""},
+		{v: "0"},
+		{v: "120", min: 120 * time.Second, max: 120 * time.Second},
+		{v: now.Add(time.Minute).UTC().Format(http.TimeFormat), min: 55 * time.Second, max: time.Minute},
+		// A date in the past due to clock skew.
+		{v: now.Add(-time.Minute).UTC().Format(http.TimeFormat)},
+		{v: "-1", err: true},
+		{v: "soon", err: true},
+	} {
+		d, err := httpheader.ParseRetryAfter(tc.v)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, but got %s", tc.v, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.v, err)
+		} else if d < tc.min || d > tc.max {
+			t.Errorf("%q: expected a duration between %s and %s, but got %s", tc.v, tc.min, tc.max, d)
+		}
+	}
+}
+
+func TestParseRateLimitReset(t *testing.T) {
+	now := time.Now().Unix()
+	for _, tc := range []struct {
+		v        string
+		min, max time.Duration
+		err      bool
+	}{
+		{v: ""},
+		{v: "0"},
+		{v: "30", min: 30 * time.Second, max: 30 * time.Second},
+		// Largest value that is still a number of seconds.
+		{v: "999999999", min: 999999999 * time.Second, max: 999999999 * time.Second},
+		// Unix timestamps, one in the past due to clock skew.
+		{v: strconv.FormatInt(now+60, 10), min: 58 * time.Second, max: time.Minute},
+		{v: strconv.FormatInt(now-5, 10)},
+		{v: "1000000000"},
+		{v: "-1", err: true},
+		{v: "1.5", err: true},
+		{v: "Wed, 21 Oct 2015 07:28:00 GMT", err: true},
+	} {
+		d, err := httpheader.ParseRateLimitReset(tc.v)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, but got %s", tc.v, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.v, err)
+		} else if d < tc.min || d > tc.max {
+			t.Errorf("%q: expected a duration between %s and %s, but got %s", tc.v, tc.min, tc.max, d)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package httpheader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureParams is a single signature from a [Signature-Input] header.
+//
+// [Signature-Input]: https://www.rfc-editor.org/rfc/rfc9421#section-4.1
+type SignatureParams struct {
+	// Label of the signature.
+	Label string
+	// Components covered by the signature, such as "@method" or
+	// "content-digest".
+	Components []string
+	// Params is the serialized value of the signature parameters, as used by
+	// the "@signature-params" component of the signature base.
+	Params string
+
+	// Created is the value of the "created" parameter, if present.
+	Created time.Time
+	// Expires is the value of the "expires" parameter, if present.
+	Expires time.Time
+	// KeyID is the value of the "keyid" parameter, if present.
+	KeyID string
+	// Algorithm is the value of the "alg" parameter, if present.
+	Algorithm string
+	// Nonce is the value of the "nonce" parameter, if present.
+	Nonce string
+	// Tag is the value of the "tag" parameter, if present.
+	Tag string
+}
+
+// ParseSignatureInput parses an HTTP [Signature-Input] header into the
+// signatures it contains.
+//
+// Components with parameters (such as `"@method";req`) are not supported and
+// result in an error.
+//
+// [Signature-Input]: https://www.rfc-editor.org/rfc/rfc9421#section-4.1
+func ParseSignatureInput(v string) ([]SignatureParams, error) {
+	var inputs []SignatureParams
+	for _, member := range splitDictionary(v) {
+		label, value, ok := strings.Cut(member, "=")
+		if !ok || !isKey(label) {
+			return nil, fmt.Errorf("nxhttp: malformed signature input '%s'", member)
+		}
+		in, err := parseSignatureParams(value)
+		if err != nil {
+			return nil, fmt.Errorf("nxhttp: malformed signature input '%s': %w", member, err)
+		}
+		in.Label = label
+		inputs = append(inputs, in)
+	}
+	return inputs, nil
+}
+
+// parseSignatureParams parses the inner list and parameters of a signature.
+func parseSignatureParams(v string) (SignatureParams, error) {
+	in := SignatureParams{Params: v}
+	if !strings.HasPrefix(v, "(") {
+		return in, fmt.Errorf("expected an inner list")
+	}
+	list, params, ok := strings.Cut(v[1:], ")")
+	if !ok {
+		return in, fmt.Errorf("unterminated inner list")
+	}
+	for item := range strings.FieldsSeq(list) {
+		name, ok := unquoteString(item)
+		if !ok {
+			return in, fmt.Errorf("unsupported component '%s'", item)
+		}
+		in.Components = append(in.Components, name)
+	}
+
+	if params == "" {
+		return in, nil
+	}
+	if params[0] != ';' {
+		return in, fmt.Errorf("expected parameters after the inner list")
+	}
+	for _, param := range splitOutside(params[1:], ';') {
+		name, value, _ := strings.Cut(param, "=")
+		switch name {
+		case "created", "expires":
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return in, fmt.Errorf("invalid '%s' parameter: %w", name, err)
+			}
+			if name == "created" {
+				in.Created = time.Unix(i, 0)
+			} else {
+				in.Expires = time.Unix(i, 0)
+			}
+		case "keyid", "alg", "nonce", "tag":
+			s, ok := unquoteString(value)
+			if !ok {
+				return in, fmt.Errorf("invalid '%s' parameter", name)
+			}
+			switch name {
+			case "keyid":
+				in.KeyID = s
+			case "alg":
+				in.Algorithm = s
+			case "nonce":
+				in.Nonce = s
+			case "tag":
+				in.Tag = s
+			}
+		}
+	}
+	return in, nil
+}
+
+// ParseSignature parses an HTTP [Signature] header into the signatures it
+// contains, keyed by their label.
+//
+// [Signature]: https://www.rfc-editor.org/rfc/rfc9421#section-4.2
+func ParseSignature(v string) (map[string][]byte, error) {
+	signatures := make(map[string][]byte)
+	for _, member := range splitDictionary(v) {
+		label, value, ok := strings.Cut(member, "=")
+		if !ok || !isKey(label) {
+			return nil, fmt.Errorf("nxhttp: malformed signature '%s'", member)
+		}
+		b, err := parseByteSequence(value)
+		if err != nil {
+			return nil, fmt.Errorf("nxhttp: malformed signature '%s': %w", member, err)
+		}
+		signatures[label] = b
+	}
+	return signatures, nil
+}
+
+// splitDictionary splits a Structured Field Dictionary into its members,
+// ignoring any commas within a quoted string or inner list.
+func splitDictionary(v string) []string {
+	return splitOutside(v, ',')
+}
+
+// splitOutside splits v into its non-empty parts separated by sep, ignoring
+// any separators within a quoted string or inner list.
+func splitOutside(v string, sep byte) []string {
+	var (
+		members []string
+		start   int
+		quoted  bool
+		depth   int
+	)
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			if m := strings.TrimSpace(v[start:i]); m != "" {
+				members = append(members, m)
+			}
+			start = i + 1
+		}
+	}
+	if m := strings.TrimSpace(v[start:]); m != "" {
+		members = append(members, m)
+	}
+	return members
+}
+
+// unquoteString unquotes a Structured Field String, reporting whether s was
+// a valid string.
+func unquoteString(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", false
+	}
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			i++
+			if i == len(s) || (s[i] != '"' && s[i] != '\\') {
+				return "", false
+			}
+			b.WriteByte(s[i])
+		case '"':
+			return "", false
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), true
+}
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// contentTypeJSON is the media type used for JSON request bodies.
+const contentTypeJSON = "application/json"
+
+// maxJSONSize is the maximum size of a response body that will be decoded by
+// [DecodeJSON], unless a limit was set using [MaxResponseBytes].
+const maxJSONSize = 16 * 1024 * 1024
+
+// JSONBody is a request body that gets encoded as JSON, see [JSON].
+type JSONBody struct {
+	v any
+}
+
+// JSON returns a request body that encodes v as JSON.
+//
+// The value is only encoded once when the body is set on a [Request], so every
+// attempt of the request sends the same data. The "Content-Type" header of the
+// request is set to "application/json" unless it was already set.
+//
+//	req, err := nxhttp.NewRequest(ctx, http.MethodPost, url, nxhttp.JSON(v))
+func JSON(v any) JSONBody {
+	return JSONBody{v: v}
+}
+
+// ContentType returns the media type of the body.
+func (JSONBody) ContentType() string {
+	return contentTypeJSON
+}
+
+// marshal encodes the value of the body as JSON.
+func (b JSONBody) marshal() ([]byte, error) {
+	data, err := json.Marshal(b.v)
+	if err != nil {
+		return nil, fmt.Errorf("nxhttp: failed to encode JSON body: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeJSON decodes the body of res as JSON into a new value of type T.
+//
+// The "Content-Type" header of the response must be "application/json" or
+// use the "+json" structured syntax suffix, otherwise a [ContentError] is
+// returned. Response bodies larger than the limit set using [MaxResponseBytes]
+// or [WithRequestMaxResponseBytes] are rejected with a [BodyTooLargeError], if
+// no limit is set, bodies larger than 16 MiB are rejected with an
+// [*http.MaxBytesError].
+//
+// The response is always closed once DecodeJSON returns.
+func DecodeJSON[T any](res *Response) (T, error) {
+	defer res.Close()
+
+	var v T
+	if err := checkJSONContentType(res); err != nil {
+		return v, err
+	}
+	body := responseBody(res)
+	if res.maxBytes == 0 {
+		body = http.MaxBytesReader(nil, body, maxJSONSize)
+	}
+	if err := json.NewDecoder(body).Decode(&v); err != nil {
+		return v, fmt.Errorf("nxhttp: failed to decode JSON response: %w", err)
+	}
+	return v, nil
+}
+
+// checkJSONContentType ensures the "Content-Type" header of res indicates the
+// body contains JSON.
+func checkJSONContentType(res *Response) error {
+	v := res.GetHeader(httpheader.ContentType)
+	mediaType, _, err := mime.ParseMediaType(v)
+	if err == nil && (mediaType == contentTypeJSON || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+	return NewContentError(httpheader.ContentType, v, contentTypeJSON)
+}
+
+// GetJSON sends a GET request to url using c and decodes the JSON response
+// into a new value of type T.
+//
+// If the response does not have a successful (2xx) status code, or one of the
+// status codes set using [ExpectStatus], a [StatusError] is returned. Responses
+// without a body (such as 204 No Content) return the zero value of T.
+//
+// The response is always closed before GetJSON returns.
+func GetJSON[T any](ctx context.Context, c *Client, url string, opts ...RequestOption) (T, error) {
+	return doJSON[T](ctx, c, http.MethodGet, url, nil, opts)
+}
+
+// SendJSON sends a request with the given method to url using c, with body
+// encoded as JSON, and decodes the JSON response into a new value of type Res.
+//
+// See [GetJSON] for details on how the response is handled.
+func SendJSON[Req, Res any](ctx context.Context, c *Client, method, url string, body Req, opts ...RequestOption) (Res, error) {
+	return doJSON[Res](ctx, c, method, url, JSON(body), opts)
+}
+
+// doJSON sends a request using c, decoding the JSON response into a new value
+// of type T.
+func doJSON[T any](ctx context.Context, c *Client, method, url string, body any, opts []RequestOption) (T, error) {
+	var v T
+	req, err := NewRequest(ctx, method, url, body)
+	if err != nil {
+		return v, err
+	}
+	req.SetHeader(httpheader.Accept, contentTypeJSON)
+
+	// [Client.Do] only validates the status code of the response if
+	// [ExpectStatus] was used, so ensure unsuccessful responses are rejected.
+	res, err := c.Do(req, append(slices.Clip(opts), expectSuccess())...)
+	if err != nil {
+		return v, err
+	}
+
+	// Avoid failing to decode responses that don't have a body.
+	if res.StatusCode == http.StatusNoContent || res.ContentLength == 0 || method == http.MethodHead {
+		_ = res.Close()
+		return v, nil
+	}
+	return DecodeJSON[T](res)
+}
+
+// StreamNDJSON returns an iterator that decodes the body of res as a stream of
+// newline-delimited JSON values (also known as NDJSON or JSON Lines), one at a
+// time, without buffering the entire body.
+//
+// If an error occurs, it is yielded along with the zero value of T and the
+// iteration stops. The response is closed once the iteration stops, including
+// when the caller breaks out of the loop early, in which case the rest of the
+// body is not read. The iterator is single-use.
+//
+//	for v, err := range nxhttp.StreamNDJSON[T](res) {
+//		if err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+func StreamNDJSON[T any](res *Response) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		closeRes := res.Close
+		defer func() { _ = closeRes() }()
+
+		dec := json.NewDecoder(responseBody(res))
+		for {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				if err != io.EOF {
+					yield(v, fmt.Errorf("nxhttp: failed to decode JSON response: %w", err))
+				}
+				return
+			}
+			if !yield(v, nil) {
+				// The rest of the stream may be slow or never end, so close
+				// the response without reading it.
+				closeRes = res.abort
+				return
+			}
+		}
+	}
+}
+
+// StreamJSONArray returns an iterator that decodes the body of res as a JSON
+// array, yielding its elements one at a time without buffering the entire
+// body.
+//
+// See [StreamNDJSON] for details on how errors and closing the response are
+// handled.
+func StreamJSONArray[T any](res *Response) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		closeRes := res.Close
+		defer func() { _ = closeRes() }()
+
+		var v T
+		dec := json.NewDecoder(responseBody(res))
+		if err := expectDelim(dec, '['); err != nil {
+			yield(v, err)
+			return
+		}
+		for dec.More() {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				yield(v, fmt.Errorf("nxhttp: failed to decode JSON response: %w", err))
+				return
+			}
+			if !yield(v, nil) {
+				// The rest of the stream may be slow or never end, so close
+				// the response without reading it.
+				closeRes = res.abort
+				return
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			yield(v, err)
+		}
+	}
+}
+
+// expectDelim reads the next token from dec, returning an error if it is not
+// the delimiter d.
+func expectDelim(dec *json.Decoder, d json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("nxhttp: failed to decode JSON response: %w", err)
+	}
+	if tok != d {
+		return fmt.Errorf("nxhttp: failed to decode JSON response: expected '%s' but got '%v'", d, tok)
+	}
+	return nil
+}
+
+// responseBody returns the body of res, or [http.NoBody] if it is nil.
+func responseBody(res *Response) io.ReadCloser {
+	if res.Body == nil {
+		return http.NoBody
+	}
+	return res.Body
+}
@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+type testItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// newJSONEchoServer returns a server that echoes the request body back using
+// the request's "Content-Type" header.
+func newJSONEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = io.Copy(w, r.Body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestDecodeJSON(t *testing.T) {
+	ts := newJSONEchoServer(t)
+	c := nxhttp.FromClient(ts.Client())
+
+	in := testItem{ID: 1, Name: "nxhttp"}
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL, nxhttp.JSON(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ContentLength <= 0 {
+		t.Errorf("expected a positive ContentLength, but got %d", req.ContentLength)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := nxhttp.DecodeJSON[testItem](res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("expected %v, but got %v", in, out)
+	}
+
+	// Ensure a mismatched Content-Type is rejected.
+	req, err = nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL, `{"id":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	res, err = c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cErr nxhttp.ContentError
+	if _, err := nxhttp.DecodeJSON[testItem](res); !errors.As(err, &cErr) {
+		t.Errorf("expected a ContentError, but got %v", err)
+	}
+}
+
+func TestDecodeJSON_MaxResponseBytes(t *testing.T) {
+	// A body larger than the default limit of 16 MiB.
+	name := strings.Repeat("a", 17*1024*1024)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":1,"name":"`+name+`"}`)
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.MaxResponseBytes(1024))
+	for _, tc := range []struct {
+		opts []nxhttp.RequestOption
+		err  bool
+	}{
+		{err: true},
+		{opts: []nxhttp.RequestOption{nxhttp.WithRequestMaxResponseBytes(32 * 1024 * 1024)}},
+	} {
+		req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Do(req, tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := nxhttp.DecodeJSON[testItem](res)
+		if tc.err {
+			var tErr nxhttp.BodyTooLargeError
+			if !errors.As(err, &tErr) || tErr.Limit != 1024 {
+				t.Errorf("expected a BodyTooLargeError, but got %v", err)
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if len(out.Name) != len(name) {
+			t.Errorf("expected a name of %d bytes, but got %d", len(name), len(out.Name))
+		}
+	}
+}
+
+func TestSendJSON(t *testing.T) {
+	ctx := context.Background()
+	ts := newJSONEchoServer(t)
+	c := nxhttp.FromClient(ts.Client())
+
+	in := testItem{ID: 2, Name: "echo"}
+	out, err := nxhttp.SendJSON[testItem, testItem](ctx, c, http.MethodPut, ts.URL, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("expected %v, but got %v", in, out)
+	}
+
+	// Ensure unexpected status codes return a StatusError.
+	ts = httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	if _, err := nxhttp.GetJSON[testItem](ctx, c, ts.URL); err == nil {
+		t.Error("expected an error")
+	} else if sErr, ok := nxhttp.AsStatusError(err); !ok || sErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a StatusError with a %d status code, but got %v", http.StatusNotFound, err)
+	}
+}
+
+func TestStreamJSON(t *testing.T) {
+	stop := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/array":
+			_, _ = io.WriteString(w, `[{"id":1},{"id":2},{"id":3}]`)
+		case "/endless":
+			// Send a single value, then never end the stream.
+			_, _ = io.WriteString(w, "{\"id\":1}\n")
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+			case <-stop:
+			}
+		default:
+			_, _ = io.WriteString(w, "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n")
+		}
+	}))
+	defer ts.Close()
+	defer close(stop)
+	c := nxhttp.FromClient(ts.Client())
+
+	for _, tc := range []struct {
+		path   string
+		stream func(*nxhttp.Response) iter.Seq2[testItem, error]
+	}{
+		{"/ndjson", nxhttp.StreamNDJSON[testItem]},
+		{"/array", nxhttp.StreamJSONArray[testItem]},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			for _, limit := range []int{3, 2} {
+				req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL+tc.path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				res, err := c.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var ids []int
+				for v, err := range tc.stream(res) {
+					if err != nil {
+						t.Fatal(err)
+					}
+					ids = append(ids, v.ID)
+					if len(ids) == limit {
+						break
+					}
+				}
+				if len(ids) != limit || ids[limit-1] != limit {
+					t.Errorf("expected %d items, but got %v", limit, ids)
+				}
+			}
+		})
+	}
+
+	t.Run("/endless", func(t *testing.T) {
+		req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL+"/endless", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Breaking out of the loop must not wait for the rest of the stream.
+		done := make(chan error, 1)
+		go func() {
+			var err error
+			for _, err = range nxhttp.StreamNDJSON[testItem](res) {
+				break
+			}
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the iterator to stop")
+		}
+	})
+}
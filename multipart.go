@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/textproto"
+	"slices"
+	"strings"
+)
+
+// Multipart is a "multipart/form-data" request body composed of fields and
+// files.
+//
+// Unlike a [multipart.Writer], a Multipart can be re-opened for every attempt
+// of a request, making it safe to retry. Parts are only opened once they are
+// read. If the sizes of all parts are known, the exact length of the body is
+// used as the ContentLength of the request.
+//
+// Multipart implements [ReadOpener] and can be used as a request body directly,
+// the "Content-Type" header of the request (including the boundary) is set
+// automatically.
+//
+//	m := nxhttp.NewMultipart()
+//	m.AddField("name", "report")
+//	if err := m.AddFile("file", "report.csv", f); err != nil {
+//		return err
+//	}
+//	req, err := nxhttp.NewRequest(ctx, http.MethodPost, url, m)
+type Multipart struct {
+	boundary string
+	parts    []multipartPart
+}
+
+var _ ReadOpener = (*Multipart)(nil)
+
+// multipartPart is a single part of a [Multipart].
+type multipartPart struct {
+	// header of the part, including the leading boundary delimiter.
+	header []byte
+	// body of the part, nil if the part is empty.
+	body BodyFunc
+	// n is the size of the body, or -1 if it is unknown.
+	n int64
+}
+
+// NewMultipart returns a new, empty [Multipart] with a random boundary.
+func NewMultipart() *Multipart {
+	return &Multipart{
+		// Re-use the boundary generation from [multipart.Writer].
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+	}
+}
+
+// Boundary returns the boundary used to separate the parts.
+func (m *Multipart) Boundary() string {
+	return m.boundary
+}
+
+// ContentType returns the media type of the body, including the boundary.
+func (m *Multipart) ContentType() string {
+	b := m.boundary
+	// We must quote the boundary if it contains any of the tspecials
+	// characters defined by RFC 2045, or space.
+	if strings.ContainsAny(b, `()<>@,;:\"/[]?= `) {
+		b = `"` + b + `"`
+	}
+	return "multipart/form-data; boundary=" + b
+}
+
+// AddField adds a form field with the given name and value.
+func (m *Multipart) AddField(name, value string) {
+	h := make(textproto.MIMEHeader, 1)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(name)))
+	// A string is always a valid body.
+	_ = m.AddPart(h, value)
+}
+
+// AddFile adds a file with the given field name and filename.
+//
+// The body of the file can be of any type accepted by [GetBody], such as a
+// [ReadOpener], [SeekableFile], [*os.File], []byte or string. The file is
+// sent with a "Content-Type" of "application/octet-stream", use [Multipart.AddPart] if
+// you need to use a different one.
+func (m *Multipart) AddFile(field, filename string, body any) error {
+	h := make(textproto.MIMEHeader, 2)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(field), escapeQuotes(filename)))
+	h.Set("Content-Type", "application/octet-stream")
+	return m.AddPart(h, body)
+}
+
+// AddPart adds a part with the given header and body.
+//
+// The body of the part can be of any type accepted by [GetBody].
+func (m *Multipart) AddPart(header textproto.MIMEHeader, body any) error {
+	fn, n, err := GetBody(body)
+	if err != nil {
+		return err
+	}
+	if fn == nil {
+		n = 0
+	}
+
+	var b bytes.Buffer
+	if len(m.parts) > 0 {
+		b.WriteString("\r\n")
+	}
+	b.WriteString("--" + m.boundary + "\r\n")
+	for _, k := range slices.Sorted(maps.Keys(header)) {
+		for _, v := range header[k] {
+			b.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+
+	m.parts = append(m.parts, multipartPart{header: b.Bytes(), body: fn, n: n})
+	return nil
+}
+
+// trailer returns the closing boundary delimiter.
+func (m *Multipart) trailer() []byte {
+	if len(m.parts) == 0 {
+		return []byte("--" + m.boundary + "--\r\n")
+	}
+	return []byte("\r\n--" + m.boundary + "--\r\n")
+}
+
+// Size returns the size of the body, or -1 if the size of any part is
+// unknown.
+func (m *Multipart) Size() int64 {
+	n := int64(len(m.trailer()))
+	for _, p := range m.parts {
+		if p.n < 0 {
+			return -1
+		}
+		n += int64(len(p.header)) + p.n
+	}
+	return n
+}
+
+// Open opens a new reader for the body, satisfying the [ReadOpener] interface.
+func (m *Multipart) Open() (io.ReadCloser, error) {
+	return &multipartReader{m: m, r: bytes.NewReader(nil)}, nil
+}
+
+// multipartReader reads the parts of a [Multipart], opening the body of each
+// part as it is reached.
+type multipartReader struct {
+	m *Multipart
+	// i is the index of the next segment.
+	i int
+	// r is the current segment being read.
+	r io.Reader
+	// body is the body of the current part if it is open.
+	body io.ReadCloser
+}
+
+// Read satisfies [io.Reader].
+func (r *multipartReader) Read(p []byte) (int, error) {
+	for {
+		// Only the end of a segment advances to the next one, as a read of
+		// (0, nil) is allowed by [io.Reader].
+		n, err := r.r.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		if err := r.next(); err != nil {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// next advances to the next segment, returning [io.EOF] once all segments
+// have been read.
+//
+// Every part has two segments, the header and the body, followed by a single
+// trailer segment once all parts have been read.
+func (r *multipartReader) next() error {
+	if r.body != nil {
+		err := r.body.Close()
+		r.body = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	i := r.i
+	switch {
+	case i > 2*len(r.m.parts):
+		return io.EOF
+	case i == 2*len(r.m.parts):
+		r.r = bytes.NewReader(r.m.trailer())
+	case i%2 == 0:
+		r.r = bytes.NewReader(r.m.parts[i/2].header)
+	default:
+		p := r.m.parts[i/2]
+		if p.body == nil {
+			r.r = bytes.NewReader(nil)
+			break
+		}
+		body, err := p.body()
+		if err != nil {
+			return fmt.Errorf("nxhttp: failed to open multipart body: %w", err)
+		}
+		r.body = body
+		r.r = body
+		if p.n >= 0 {
+			// Avoid writing more data than we reported as the ContentLength.
+			r.r = io.LimitReader(body, p.n)
+		}
+	}
+	r.i++
+	return nil
+}
+
+// Close satisfies [io.Closer].
+func (r *multipartReader) Close() error {
+	r.i = 2*len(r.m.parts) + 1
+	r.r = bytes.NewReader(nil)
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// quoteEscaper escapes quotes and backslashes in a Content-Disposition
+// parameter, the same as [multipart.Writer].
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes quotes and backslashes in s.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
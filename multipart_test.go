@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+func TestMultipart(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "upload.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("file contents"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := nxhttp.NewMultipart()
+	m.AddField("name", `say "hello"`)
+	if err := m.AddFile("file", "upload.txt", f); err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := attempts.Add(1)
+		if r.ContentLength != m.Size() {
+			t.Errorf("expected ContentLength to be %d, but got %d", m.Size(), r.ContentLength)
+		}
+		if err := r.ParseMultipartForm(1024); err != nil {
+			t.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if v := r.FormValue("name"); v != `say "hello"` {
+			t.Errorf("unexpected field value: %q", v)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		if b, _ := io.ReadAll(file); string(b) != "file contents" || header.Filename != "upload.txt" {
+			t.Errorf("unexpected file %q with contents %q", header.Filename, b)
+		}
+
+		// Fail the first attempt to ensure the body can be sent again.
+		if attempt == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPut, ts.URL, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff())).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Close()
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("expected 2 attempts, but got %d", got)
+	}
+}
+
+// stutterReader is an [io.Reader] that returns (0, nil) before every read.
+type stutterReader struct {
+	r       io.Reader
+	stutter bool
+}
+
+func (r *stutterReader) Read(p []byte) (int, error) {
+	if r.stutter = !r.stutter; r.stutter {
+		return 0, nil
+	}
+	return r.r.Read(p[:min(len(p), 4)])
+}
+
+func TestMultipart_EmptyReads(t *testing.T) {
+	const contents = "file contents that are read a few bytes at a time"
+	m := nxhttp.NewMultipart()
+	body := nxhttp.ReadOpenerFor(func() (io.ReadCloser, error) {
+		return io.NopCloser(&stutterReader{r: strings.NewReader(contents)}), nil
+	}, int64(len(contents)))
+	if err := m.AddFile("file", "upload.txt", body); err != nil {
+		t.Fatal(err)
+	}
+	m.AddField("name", "value")
+
+	r, err := m.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(b)) != m.Size() {
+		t.Errorf("expected to read %d bytes, but got %d", m.Size(), len(b))
+	}
+	if !strings.Contains(string(b), "\r\n\r\n"+contents+"\r\n") {
+		t.Errorf("expected the body to contain the entire file, but got %q", b)
+	}
+}
@@ -6,9 +6,13 @@
 package nxhttp
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/matthewpi/nxhttp/httpheader"
 	"github.com/matthewpi/nxretry"
 )
 
@@ -68,6 +72,14 @@ func NewClient(opts ...ClientOption) *Client {
 }
 
 // Do sends an HTTP request and returns an HTTP response.
+//
+// Failed attempts are retried according to the configured [RetryPolicy]. If
+// the final attempt would have been retried but no attempts remain (or the
+// request's context is done), a [RetryExhaustedError] is returned containing
+// every attempt that was made, and the final response (if any) is closed.
+//
+// If a cache is configured (see [WithCache]), GET requests may be served from
+// the cache without being sent to the server, see [Response.FromCache].
 func (c *Client) Do(req *Request, opts ...RequestOption) (*Response, error) {
 	ctx := req.Context()
 	if err := ctx.Err(); err != nil {
@@ -83,136 +95,195 @@ func (c *Client) Do(req *Request, opts ...RequestOption) (*Response, error) {
 		}
 	}
 
+	// If configured, use the cache to send the request.
+	if c.cache != nil {
+		return c.cache.do(c, req, opts)
+	}
+	return c.do(req, opts)
+}
+
+// do sends an HTTP request and returns an HTTP response, retrying it as
+// necessary. See [Client.Do] for details.
+func (c *Client) do(req *Request, opts []RequestOption) (*Response, error) {
+	ctx := req.Context()
 	httpClient := c.client
 
 	// Handle options for the request if present.
-	if len(opts) > 0 {
-		reqOpts := &requestOptions{}
-		for _, opt := range opts {
-			opt.apply(reqOpts)
+	reqOpts := c.requestOptions()
+	for _, opt := range opts {
+		opt.apply(reqOpts)
+	}
+
+	var rt http.RoundTripper
+	if reqOpts.transport != nil {
+		t := c.transport.Clone()
+		reqOpts.transport(t)
+		rt = t
+	}
+	if reqOpts.roundTripper != nil {
+		if rt == nil {
+			rt = c.transport.Clone()
 		}
+		rt = reqOpts.roundTripper(rt)
+	}
 
-		var rt http.RoundTripper
-		if reqOpts.transport != nil {
-			t := c.transport.Clone()
-			reqOpts.transport(t)
-			rt = t
+	// If the transport was overridden, create a new HTTP Client that
+	// uses the transport.
+	if rt != nil {
+		httpClient = &http.Client{
+			Transport:     rt,
+			CheckRedirect: httpClient.CheckRedirect,
+			Jar:           httpClient.Jar,
+			Timeout:       httpClient.Timeout,
 		}
-		if reqOpts.roundTripper != nil {
-			if rt == nil {
-				rt = c.transport.Clone()
-			}
-			rt = reqOpts.roundTripper(rt)
+	}
+
+	// If configured, compress the body of the request.
+	if reqOpts.compressor != nil {
+		var err error
+		if req, err = reqOpts.compressor.compress(req); err != nil {
+			return nil, err
 		}
+	}
 
-		// If the transport was overridden, create a new HTTP Client that
-		// uses the transport.
-		if rt != nil {
-			httpClient = &http.Client{
-				Transport:     rt,
-				CheckRedirect: httpClient.CheckRedirect,
-				Jar:           httpClient.Jar,
-				Timeout:       httpClient.Timeout,
-			}
+	// If configured, calculate the digest of the body of the request. This is
+	// done after compressing the body as the digest is of the content that is
+	// actually sent.
+	if len(reqOpts.contentDigest) > 0 && req.body != nil && httpheader.Get(req.Header, httpheader.ContentDigest) == "" {
+		v, err := contentDigest(req.body, reqOpts.contentDigest)
+		if err != nil {
+			return nil, err
 		}
+		req = req.clone()
+		req.SetHeader(httpheader.ContentDigest, v)
 	}
 
+	// If configured, advertise the encodings we are able to decompress. This
+	// is skipped if the caller set the header themselves, in which case they
+	// are expected to handle decompressing the response.
+	decompressResponse := c.decompress && httpheader.Get(req.Header, httpheader.AcceptEncoding) == ""
+	if decompressResponse {
+		req = req.clone()
+		req.SetHeader(httpheader.AcceptEncoding, acceptEncoding)
+	}
+
+	// If configured, generate an idempotency key for non-idempotent requests
+	// that don't already have one. The key is generated once per call to Do,
+	// so every attempt of the request shares it.
+	if c.generateIdempotencyKey && !isIdempotent(req.Method) && httpheader.Get(req.Header, httpheader.IdempotencyKey) == "" {
+		req = req.clone()
+		req.SetHeader(httpheader.IdempotencyKey, rand.Text())
+	}
+
+	// Determine if the request is safe to replay at all, if not, any failed
+	// attempt is final regardless of what the retry policy says.
+	retryable := reqOpts.retryNonIdempotent || isReplayable(req)
+
 	var (
-		r     *Response
-		doErr error
+		r        *Response
+		doErr    error
+		attempt  uint
+		attempts []Attempt
+		// exhausted indicates the final attempt would have been retried,
+		// but we ran out of attempts or the context was done.
+		exhausted bool
 	)
 	// Configure the retrier for the request.
 	rty := nxretry.New(
-		nxretry.MaxAttempts(c.maxAttempts),
-		c.backoff,
+		nxretry.MaxAttempts(reqOpts.maxAttempts),
+		reqOpts.backoff,
 	)
-out:
+	start := time.Now()
 	for range rty.Next(ctx) {
+		attempt++
+
+		// Close the response from the previous attempt (if any) as it is
+		// being replaced and would otherwise be leaked.
+		if r != nil {
+			_ = r.Close()
+		}
+
 		// Execute the request.
-		r, doErr = doRequest(httpClient, req)
+		r, doErr = c.doAttempt(ctx, httpClient, req, reqOpts, decompressResponse)
+
+		a := Attempt{Request: req, Number: attempt}
 		if doErr != nil {
 			// Allow the caller to process the error before we do.
 			//
 			// This can be used for logging or to transform errors such as
 			// permanent to temporary or vice versa.
 			doErr = c.onError(ctx, doErr)
+			a.Err = doErr
 
-			// Only retry here if the error is retryable. We don't want to keep
-			// retrying a broken request such as one with a malformed URL, but
-			// we do for a connection timeout (as an example).
-			if doErr == nil || isTimeout(doErr) {
-				continue
-			}
-
-			// Otherwise, treat the error as permanent and don't retry the request.
-			break
+			// Let the retry policy decide whether the error is retryable,
+			// otherwise treat it as permanent and don't retry the request.
+			a.Retry = doErr == nil || (retryable && reqOpts.retryPolicy(ctx, a))
+		} else {
+			a.StatusCode = r.StatusCode
+			doErr = c.checkResponse(ctx, reqOpts, &a, r, retryable)
 		}
+		a.Elapsed = time.Since(start)
 
-		// If we got a successful status code, return the response immediately
-		// without any additional processing.
-		if r.StatusCode >= http.StatusOK && r.StatusCode <= 299 {
-			break
-		}
+		// Only report a retry if there is actually an attempt remaining.
+		exhausted = a.Retry
+		a.Retry = a.Retry && (reqOpts.maxAttempts == 0 || attempt < reqOpts.maxAttempts)
 
-		// If the caller provided a handler for an error response, call it
-		// to determine if we should continue or not.
-		if c.onErrorResponse != nil {
-			doErr = c.onErrorResponse(ctx, r)
-			if doErr != nil {
-				break
+		// If the server requested a delay, use it for the next attempt instead
+		// of whatever the normal backoff would provide. Otherwise, the delay
+		// from the backoff is used, which is overridden as well so that the
+		// delay reported to the caller is the one that is actually used.
+		if a.Retry {
+			a.Delay = a.RetryAfter
+			if a.Delay <= 0 {
+				a.Delay = backoffDelay(reqOpts.backoff, attempt)
+			}
+			if a.Delay > 0 {
+				rty.Override(a.Delay)
 			}
 		}
+		attempts = append(attempts, a)
 
-		// Depending on the status code of the response, determine if the
-		// request should be retried.
-		//
-		// TODO: add an option on the client to allow/deny additional codes.
-		switch r.StatusCode {
-		case http.StatusTooManyRequests:
-		case http.StatusInternalServerError:
-		case http.StatusBadGateway:
-		case http.StatusServiceUnavailable:
-		case http.StatusGatewayTimeout:
-		default:
-			// The request was either successful or we hit a fatal error, either way
-			// we are done.
-			break out
-		}
-
-		// Get the duration we should wait from the Retry-After header.
-		d, err := r.retryAfter()
-		if err != nil {
-			// TODO: do we want to do something about the Retry-After error?
-			//
-			// If there is an error it means a malformed Retry-After was sent
-			// and we may want to let the user know that. That way they can
-			// fix the server (if they control it) or inform the server operator
-			// about the issue.
-			continue
+		if c.onAttempt != nil {
+			c.onAttempt(ctx, a)
 		}
 
-		// Only override the retrier if the Retry-After was parsed and
-		// is above our minimum, otherwise fallback to the standard
-		// backoff.
-		if d > c.minRetryAfter {
-			// Ensure the duration does not exceed our configured maximum
-			// if configured.
-			if c.maxRetryAfter > 0 && d > c.maxRetryAfter {
-				// Truncate the duration to our maximum value.
-				d = c.maxRetryAfter
-			}
-
-			// We got a valid Retry-After from the server, use as next delay
-			// for the retrier instead of whatever the normal backoff would
-			// provide.
-			rty.Override(d)
+		// The request was either successful or we hit a fatal error, either
+		// way we are done.
+		if !a.Retry {
+			break
 		}
 
 		// Retry the request. If there was a Retry-After header in the
 		// response, it will be respected. Otherwise, the [nxretry.Backoff]
 		// that was configured will be used to determine the delay for the
 		// next attempt.
-		continue
+	}
+
+	// If we ran out of attempts, return an error containing the history of
+	// every attempt rather than only the final one.
+	if exhausted && (r != nil || doErr != nil) {
+		if r != nil {
+			doErr = NewStatusError(r, reqOpts.expectStatus...)
+		}
+		// If attempts remained, the request's context must have been done
+		// before the next attempt could be made.
+		var ctxErr error
+		if attempts[len(attempts)-1].Retry {
+			ctxErr = ctx.Err()
+		}
+		return nil, RetryExhaustedError{Attempts: attempts, Err: doErr, ContextErr: ctxErr}
+	}
+
+	// If the caller configured the status codes they expect, ensure the final
+	// response matches one of them.
+	if doErr == nil && r != nil && reqOpts.isUnexpected(r.StatusCode) {
+		return nil, NewStatusError(r, reqOpts.expectStatus...)
+	}
+
+	// Record how many attempts it took to get the response.
+	if r != nil {
+		r.attempts = attempt
+		r.elapsed = time.Since(start)
 	}
 
 	// Return the response and error. It is very likely one of them is nil, but
@@ -220,6 +291,137 @@ out:
 	return r, doErr
 }
 
+// doAttempt executes a single attempt of req, wrapping the body of the response
+// according to the options.
+func (c *Client) doAttempt(ctx context.Context, httpClient *http.Client, req *Request, o *requestOptions, decompressResponse bool) (*Response, error) {
+	// If a body timeout is configured, give the attempt its own context so
+	// reading the body can be interrupted once the timeout is reached.
+	var cancel context.CancelFunc
+	if o.bodyReadTimeout > 0 || o.bodyIdleTimeout > 0 {
+		ctx, cancel = context.WithCancel(ctx)
+		req = req.WithContext(ctx)
+	}
+
+	r, err := send(ctx, httpClient, req, o)
+	if err != nil || r == nil {
+		if cancel != nil {
+			cancel()
+		}
+		return r, err
+	}
+
+	if err := c.wrapResponse(r, o, decompressResponse); err != nil {
+		_ = r.Close()
+		if cancel != nil {
+			cancel()
+		}
+		return nil, err
+	}
+	if cancel != nil {
+		r.Body = newTimeoutReadCloser(r.Body, cancel, o.bodyReadTimeout, o.bodyIdleTimeout)
+	}
+	return r, nil
+}
+
+// wrapResponse verifies r and wraps its body according to the options.
+func (c *Client) wrapResponse(r *Response, o *requestOptions, decompressResponse bool) error {
+	// If configured, verify the signature of the response. This may also
+	// verify the digest of the body, so it must happen before the body is
+	// decompressed.
+	if o.responseVerifier != nil {
+		if err := o.responseVerifier.VerifyResponse(r); err != nil {
+			return err
+		}
+	}
+
+	// The digest is of the content as it was received, so it must be verified
+	// before the body is decompressed. If the transport already decompressed
+	// the body itself, the digest can't be verified. Responses without a body
+	// may still describe the digest of the representation, which is ignored.
+	if o.verifyDigests && r.hasBody() && !r.Uncompressed {
+		if err := verifyDigest(r); err != nil {
+			return err
+		}
+	}
+	if o.responseProgress != nil && r.Body != nil {
+		r.Body = newProgressReadCloser(r.Body, r.ContentLength, o.responseProgress)
+	}
+	if decompressResponse {
+		decompress(r, c.maxDecompressedSize)
+	}
+	if o.maxResponseBytes > 0 && r.Body != nil {
+		r.Body = newLimitedReadCloser(r.Body, o.maxResponseBytes)
+		r.maxBytes = o.maxResponseBytes
+	}
+	return nil
+}
+
+// checkResponse determines whether the attempt that returned r should be
+// retried, and if so, the delay requested by the server (if any).
+//
+// The Retry, RetryAfter and Err fields of a are set accordingly. A zero
+// RetryAfter indicates the configured [nxretry.Backoff] should be used to
+// determine the delay before the next attempt.
+func (c *Client) checkResponse(ctx context.Context, o *requestOptions, a *Attempt, r *Response, retryable bool) error {
+	// If we got a successful (or expected) status code, return the response
+	// immediately without any additional processing.
+	if o.isExpected(r.StatusCode) {
+		return nil
+	}
+
+	// If the caller provided a handler for an error response, call it
+	// to determine if we should continue or not.
+	if c.onErrorResponse != nil {
+		if err := c.onErrorResponse(ctx, r); err != nil {
+			return err
+		}
+	}
+
+	// Depending on the status code of the response, determine if the
+	// request should be retried.
+	if !retryable || !o.retryPolicy(ctx, *a) {
+		return nil
+	}
+
+	// Get the duration we should wait from the Retry-After header.
+	d, err := r.retryAfter()
+	if err != nil {
+		// If there is an error it means a malformed Retry-After was sent and
+		// we want to let the user know that. That way they can fix the server
+		// (if they control it) or inform the server operator about the issue.
+		//
+		// The error is recorded on the attempt, and the request is retried
+		// using the standard backoff unless the caller decides otherwise.
+		a.Err = err
+		if c.onRetryAfterError != nil {
+			if err := c.onRetryAfterError(ctx, err); err != nil {
+				return err
+			}
+		}
+		a.Retry = true
+		return nil
+	}
+	a.Retry = true
+
+	// Only override the retrier if the Retry-After was parsed and
+	// is above our minimum, otherwise fallback to the standard
+	// backoff.
+	if d <= o.minRetryAfter {
+		return nil
+	}
+
+	// Ensure the duration does not exceed our configured maximum
+	// if configured.
+	if o.maxRetryAfter > 0 && d > o.maxRetryAfter {
+		// Truncate the duration to our maximum value.
+		d = o.maxRetryAfter
+	}
+
+	// We got a valid Retry-After from the server.
+	a.RetryAfter = d
+	return nil
+}
+
 // do wraps a [http.Client.Do] method.
 func doRequest(c *http.Client, req *Request) (*Response, error) {
 	// Check if our custom body type is set, while we end up using the
@@ -250,10 +452,12 @@ func doRequest(c *http.Client, req *Request) (*Response, error) {
 	// If we have a response with a body, wrap it with [discardReadCloser] so
 	// when the body gets closed, we ensure its contents get read to completion
 	// so the response can get reused for future requests.
+	r := &Response{Response: res}
 	if res.Body != nil {
-		res.Body = &discardReadCloser{ReadCloser: res.Body}
+		r.discard = &discardReadCloser{ReadCloser: res.Body}
+		res.Body = r.discard
 	}
 
 	// Wrap the response.
-	return &Response{Response: res}, nil
+	return r, nil
 }
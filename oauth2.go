@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// OAuth2Config is the configuration of an OAuth2 client used to fetch tokens
+// from a token endpoint.
+type OAuth2Config struct {
+	// TokenURL is the URL of the token endpoint.
+	TokenURL string
+	// ClientID of the client.
+	ClientID string
+	// ClientSecret of the client.
+	ClientSecret string
+	// Scopes to request, if any.
+	Scopes []string
+	// Params are any additional parameters to send to the token endpoint,
+	// such as "audience" or "resource".
+	Params url.Values
+	// CredentialsInBody sends the client credentials as parameters in the
+	// body of the request, instead of using HTTP Basic Authentication.
+	CredentialsInBody bool
+}
+
+// OAuth2TokenSource is a [TokenSource] that fetches tokens from an OAuth2
+// (RFC 6749) token endpoint using either the client credentials or refresh
+// token grant.
+//
+// OAuth2TokenSource fetches a new token every time [OAuth2TokenSource.Token]
+// is called, use it with a [TokenAuthenticator] to cache tokens and refresh
+// them before they expire.
+type OAuth2TokenSource struct {
+	client *Client
+	config OAuth2Config
+	opts   []RequestOption
+
+	// grantType used to request tokens.
+	grantType string
+
+	// mu guards refreshToken, which is replaced if the server issues a new
+	// refresh token.
+	mu           sync.Mutex
+	refreshToken string
+}
+
+var _ TokenSource = (*OAuth2TokenSource)(nil)
+
+// NewClientCredentialsSource returns a new [OAuth2TokenSource] using the client
+// credentials grant, sending requests to the token endpoint using c and opts.
+// Requests to the token endpoint are never authenticated or signed by the
+// [Authenticator] or [RequestSigner] of c.
+//
+// As the client credentials grant has no side effects, requests to the token
+// endpoint are retried like any idempotent request.
+func NewClientCredentialsSource(c *Client, config OAuth2Config, opts ...RequestOption) *OAuth2TokenSource {
+	return &OAuth2TokenSource{
+		client:    c,
+		config:    config,
+		opts:      append([]RequestOption{WithRequestAuthenticator(nil), WithRequestSigner(nil), RetryNonIdempotent()}, opts...),
+		grantType: "client_credentials",
+	}
+}
+
+// NewRefreshTokenSource returns a new [OAuth2TokenSource] using the refresh
+// token grant, sending requests to the token endpoint using c and opts.
+// Requests to the token endpoint are never authenticated or signed by the
+// [Authenticator] or [RequestSigner] of c.
+//
+// If the server issues a new refresh token along with a token, it replaces
+// refreshToken for any further requests.
+func NewRefreshTokenSource(c *Client, config OAuth2Config, refreshToken string, opts ...RequestOption) *OAuth2TokenSource {
+	return &OAuth2TokenSource{
+		client:       c,
+		config:       config,
+		opts:         append([]RequestOption{WithRequestAuthenticator(nil), WithRequestSigner(nil)}, opts...),
+		grantType:    "refresh_token",
+		refreshToken: refreshToken,
+	}
+}
+
+// oauth2Token is a successful response from a token endpoint.
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Token fetches a new token from the token endpoint.
+func (s *OAuth2TokenSource) Token(ctx context.Context) (*Token, error) {
+	params := url.Values{}
+	for k, v := range s.config.Params {
+		params[k] = v
+	}
+	params.Set("grant_type", s.grantType)
+	if len(s.config.Scopes) > 0 {
+		params.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.grantType == "refresh_token" {
+		s.mu.Lock()
+		params.Set("refresh_token", s.refreshToken)
+		s.mu.Unlock()
+	}
+	if s.config.CredentialsInBody {
+		params.Set("client_id", s.config.ClientID)
+		if s.config.ClientSecret != "" {
+			params.Set("client_secret", s.config.ClientSecret)
+		}
+	}
+
+	req, err := NewRequest(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetHeader(httpheader.ContentType, "application/x-www-form-urlencoded")
+	req.SetHeader(httpheader.Accept, contentTypeJSON)
+	if !s.config.CredentialsInBody {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	start := time.Now()
+	res, err := s.client.Do(req, s.opts...)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		sErr := NewStatusError(res, http.StatusOK)
+		oErr := OAuth2Error{StatusCode: sErr.StatusCode}
+		if err := json.Unmarshal(sErr.Data, &oErr); err != nil || oErr.Code == "" {
+			return nil, sErr
+		}
+		return nil, oErr
+	}
+
+	t, err := DecodeJSON[oauth2Token](res)
+	if err != nil {
+		return nil, err
+	}
+	if t.AccessToken == "" {
+		return nil, errors.New("nxhttp: token endpoint response is missing an access token")
+	}
+
+	tok := &Token{AccessToken: t.AccessToken, TokenType: t.TokenType}
+	// The token type is case-insensitive, but many servers only accept the
+	// canonical form in the "Authorization" header.
+	if strings.EqualFold(tok.TokenType, "bearer") {
+		tok.TokenType = "Bearer"
+	}
+	if t.ExpiresIn > 0 {
+		// Use the time the request was sent, so the token is considered
+		// expired slightly before it actually is.
+		tok.Expiry = start.Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	if t.RefreshToken != "" && s.grantType == "refresh_token" {
+		s.mu.Lock()
+		s.refreshToken = t.RefreshToken
+		s.mu.Unlock()
+	}
+	return tok, nil
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+// newTokenServer returns a server implementing an OAuth2 token endpoint, and
+// a pointer to the number of tokens it issued.
+func newTokenServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var (
+		n  atomic.Int32
+		mu sync.Mutex
+		// refreshToken is the currently valid refresh token.
+		refreshToken = "refresh-1"
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad credentials"}`)
+			return
+		}
+
+		var next string
+		switch r.PostFormValue("grant_type") {
+		case "client_credentials":
+			if r.PostFormValue("scope") != "read write" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"error":"invalid_scope"}`)
+				return
+			}
+		case "refresh_token":
+			mu.Lock()
+			defer mu.Unlock()
+			if r.PostFormValue("refresh_token") != refreshToken {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+			refreshToken = fmt.Sprintf("refresh-%d", n.Load()+2)
+			next = refreshToken
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"error":"unsupported_grant_type"}`)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600,"refresh_token":%q}`, n.Add(1), next)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &n
+}
+
+// signerFunc is an adapter to use a function as a [nxhttp.RequestSigner].
+type signerFunc func(context.Context, *nxhttp.Request) error
+
+func (f signerFunc) SignRequest(ctx context.Context, req *nxhttp.Request) error { return f(ctx, req) }
+
+// noSigner is a [nxhttp.RequestSigner] that fails every request, used to
+// ensure requests to the token endpoint are not signed.
+var noSigner = signerFunc(func(context.Context, *nxhttp.Request) error {
+	return errors.New("requests to the token endpoint must not be signed")
+})
+
+func TestClientCredentialsSource(t *testing.T) {
+	ts, n := newTokenServer(t)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer api.Close()
+
+	c := nxhttp.FromClient(http.DefaultClient, nxhttp.WithSigner(noSigner))
+	source := nxhttp.NewClientCredentialsSource(c, nxhttp.OAuth2Config{
+		TokenURL:     ts.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+	c = nxhttp.FromClient(http.DefaultClient, nxhttp.WithAuthenticator(nxhttp.NewTokenAuthenticator(source)))
+	for range 3 {
+		req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, api.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("expected a 200 response, but got %d", res.StatusCode)
+		}
+	}
+	if got := n.Load(); got != 1 {
+		t.Errorf("expected 1 token to be issued, but got %d", got)
+	}
+
+	tok, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.TokenType != "Bearer" || time.Until(tok.Expiry) < 59*time.Minute {
+		t.Errorf("unexpected token %+v", tok)
+	}
+
+	// Invalid credentials should return the error from the token endpoint.
+	_, err = nxhttp.NewClientCredentialsSource(c, nxhttp.OAuth2Config{
+		TokenURL: ts.URL,
+		ClientID: "client",
+	}).Token(context.Background())
+	var oErr nxhttp.OAuth2Error
+	if !errors.As(err, &oErr) || oErr.Code != "invalid_client" || oErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected an invalid_client OAuth2Error, but got %v", err)
+	}
+}
+
+func TestRefreshTokenSource(t *testing.T) {
+	ts, _ := newTokenServer(t)
+	source := nxhttp.NewRefreshTokenSource(nxhttp.FromClient(http.DefaultClient, nxhttp.WithSigner(noSigner)), nxhttp.OAuth2Config{
+		TokenURL:     ts.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}, "refresh-1")
+
+	// The refresh token is rotated on every request, so the second request
+	// only succeeds if the new refresh token was used.
+	for i := range 2 {
+		tok, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if expected := fmt.Sprintf("token-%d", i+1); tok.AccessToken != expected {
+			t.Errorf("#%d: expected access token to be '%s', but got '%s'", i, expected, tok.AccessToken)
+		}
+	}
+}
@@ -20,6 +20,38 @@ type options struct {
 	// defaultHeaders for all http requests.
 	defaultHeaders map[httpheader.Key]string
 
+	// decompress enables transparent decompression of response bodies.
+	decompress bool
+
+	// maxDecompressedSize is the maximum size of a decompressed response
+	// body, if zero the size is unlimited.
+	maxDecompressedSize int64
+
+	// maxResponseBytes is the maximum size of a response body, if zero the
+	// size is unlimited.
+	maxResponseBytes int64
+
+	// bodyReadTimeout is the maximum duration for reading an entire response
+	// body after the headers are received, if zero there is no timeout.
+	bodyReadTimeout time.Duration
+
+	// bodyIdleTimeout is the maximum duration between reads of a response
+	// body that return data, if zero there is no timeout.
+	bodyIdleTimeout time.Duration
+
+	// authenticator adds credentials to every attempt of a request.
+	authenticator Authenticator
+
+	// signer signs every attempt of a request.
+	signer RequestSigner
+
+	// cache used for GET requests, if nil caching is disabled.
+	cache *cache
+
+	// verifyDigests enables verification of response bodies against their
+	// "Content-Digest" and "Repr-Digest" headers.
+	verifyDigests bool
+
 	//
 	// nxretry
 	//
@@ -46,6 +78,13 @@ type options struct {
 	// truncated (`min(Retry-After, maxRetryAfter)`).
 	maxRetryAfter time.Duration
 
+	// retryPolicy decides whether a failed attempt should be retried.
+	retryPolicy RetryPolicy
+
+	// generateIdempotencyKey enables generating an "Idempotency-Key" header
+	// for non-idempotent requests that don't already have one.
+	generateIdempotencyKey bool
+
 	//
 	// other options
 	//
@@ -57,6 +96,13 @@ type options struct {
 	// onErrorResponse .
 	// TODO: document
 	onErrorResponse ErrorResponseFunc
+
+	// onAttempt is called after every attempt made by [Client.Do].
+	onAttempt AttemptFunc
+
+	// onRetryAfterError is called with a [RetryAfterError] whenever a
+	// response contains a malformed "Retry-After" header.
+	onRetryAfterError ErrorFunc
 }
 
 // newOptions creates a new [options] instance with any defaults.
@@ -79,6 +125,10 @@ func (o *options) setDefaults() {
 		}
 	}
 
+	if o.retryPolicy == nil {
+		o.retryPolicy = DefaultRetryPolicy
+	}
+
 	if o.onError == nil {
 		o.onError = func(_ context.Context, err error) error { return err }
 	}
@@ -130,10 +180,122 @@ func WithDefaultHeaders(h map[httpheader.Key]string) OptionFunc {
 	}
 }
 
+// DecompressResponses enables transparent decompression of response bodies.
+//
+// Requests without an "Accept-Encoding" header will advertise support for the
+// "zstd", "br", "gzip" and "deflate" encodings, and response bodies using any
+// of them are decompressed as they are read. Like [http.Transport], the
+// "Content-Encoding" and "Content-Length" headers are removed from responses
+// that are decompressed.
+//
+// To protect against decompression bombs, reading more than maxSize bytes of
+// a decompressed body returns a [BodyTooLargeError]. If maxSize is 0, the
+// size of decompressed bodies is unlimited.
+func DecompressResponses(maxSize int64) OptionFunc {
+	return func(o *options) {
+		o.decompress = true
+		o.maxDecompressedSize = maxSize
+	}
+}
+
+// MaxResponseBytes limits the size of response bodies, reading more than n
+// bytes from a response body returns a [BodyTooLargeError].
+//
+// If response decompression is enabled, the limit applies to the decompressed
+// body. If n is 0, the size of response bodies is unlimited.
+func MaxResponseBytes(n int64) OptionFunc {
+	return func(o *options) { o.maxResponseBytes = n }
+}
+
+// BodyReadTimeout limits the time spent reading a response body after its
+// headers were received. Once the timeout is reached, reading the body
+// returns a [BodyTimeoutError].
+//
+// If d is 0, there is no timeout.
+func BodyReadTimeout(d time.Duration) OptionFunc {
+	return func(o *options) { o.bodyReadTimeout = d }
+}
+
+// BodyIdleTimeout limits the time between reads of a response body that
+// return data. If no data is received for d, reading the body returns a
+// [BodyTimeoutError].
+//
+// Unlike [BodyReadTimeout], this allows large (or long-lived) response bodies
+// to be read as long as data continues to arrive. If d is 0, there is no
+// timeout.
+func BodyIdleTimeout(d time.Duration) OptionFunc {
+	return func(o *options) { o.bodyIdleTimeout = d }
+}
+
+// WithAuthenticator sets the [Authenticator] used to add credentials to every
+// attempt of a request.
+//
+// If the [Authenticator] also implements [ChallengeHandler], it is used to
+// handle "401 Unauthorized" responses, such as by refreshing a token before
+// the request is sent again.
+func WithAuthenticator(a Authenticator) OptionFunc {
+	return func(o *options) { o.authenticator = a }
+}
+
+// WithSigner sets the [RequestSigner] used to sign every attempt of a request,
+// such as a [MessageSigner] or [SigV4Signer].
+//
+// Requests are signed after they are authenticated by the [Authenticator] (if
+// any), so the signature may cover the credentials.
+func WithSigner(s RequestSigner) OptionFunc {
+	return func(o *options) { o.signer = s }
+}
+
+// WithCache enables a private HTTP cache (RFC 9111) for GET requests, storing
+// responses in store (such as a [MemoryCache] or [DiskCache]).
+//
+// Fresh responses are served from the cache without contacting the server,
+// while stale responses are revalidated using the "If-None-Match" and
+// "If-Modified-Since" headers. The "Cache-Control" directives "no-store",
+// "no-cache", "max-age", "stale-while-revalidate" and "stale-if-error" are
+// respected, as is the "Vary" header of responses. Responses are stored once
+// their body has been read to completion, and successful requests using
+// unsafe methods invalidate the stored response for the same URL.
+//
+// Responses served from the cache are subject to the same [RequestOption] as
+// responses received from the server, such as [ExpectStatus].
+//
+// Errors from store are ignored, causing the request to be sent to the
+// server as if caching was disabled.
+func WithCache(store CacheStore) OptionFunc {
+	return func(o *options) {
+		if store == nil {
+			o.cache = nil
+			return
+		}
+		o.cache = &cache{store: store}
+	}
+}
+
+// VerifyDigests enables verification of response bodies against the
+// "Content-Digest" and "Repr-Digest" headers sent by the server (RFC 9530).
+//
+// The body is hashed as it is read, and once the end of the body is reached,
+// reading it returns a [DigestError] instead of [io.EOF] if the digest doesn't
+// match. Only the strongest supported algorithm of each header is verified,
+// and responses without a digest are not verified.
+func VerifyDigests() OptionFunc {
+	return func(o *options) { o.verifyDigests = true }
+}
+
 //
 // nxretry options
 //
 
+// WithBackoff sets the [nxretry.Backoff] used to control the delay between
+// attempts.
+//
+// If not set, an [nxretry.Exponential] backoff starting at 1 second and capped
+// at 5 seconds is used.
+func WithBackoff(b nxretry.Backoff) OptionFunc {
+	return func(o *options) { o.backoff = b }
+}
+
 // MaxAttempts sets the maximum number of attempts that can occur. If set to 0
 // the maximum number of attempts will be unlimited.
 func MaxAttempts(maxAttempts uint) OptionFunc {
@@ -152,6 +314,23 @@ func MaxRetryAfter(d time.Duration) OptionFunc {
 	return func(o *options) { o.maxRetryAfter = d }
 }
 
+// WithRetryPolicy sets the [RetryPolicy] used to decide whether a failed
+// attempt should be retried. If not set, [DefaultRetryPolicy] is used.
+func WithRetryPolicy(p RetryPolicy) OptionFunc {
+	return func(o *options) { o.retryPolicy = p }
+}
+
+// GenerateIdempotencyKey enables generating a random "Idempotency-Key" header
+// for requests using a non-idempotent method (such as POST or PATCH) that do
+// not already have one.
+//
+// The key is generated once per call to [Client.Do], so every attempt of the
+// same request shares it. This allows those requests to be retried, as the
+// server can use the key to deduplicate them.
+func GenerateIdempotencyKey() OptionFunc {
+	return func(o *options) { o.generateIdempotencyKey = true }
+}
+
 //
 // other options
 //
@@ -180,3 +359,25 @@ type ErrorResponseFunc func(context.Context, *Response) error
 func OnErrorResponse(fn ErrorResponseFunc) OptionFunc {
 	return func(o *options) { o.onErrorResponse = fn }
 }
+
+// OnAttempt sets a function that is called after every attempt made by
+// [Client.Do], including the final one.
+//
+// The [Attempt] describes the outcome of the attempt, whether it will be
+// retried and the delay before the next attempt, making it useful for logging
+// or alerting on flaky upstreams.
+func OnAttempt(fn AttemptFunc) OptionFunc {
+	return func(o *options) { o.onAttempt = fn }
+}
+
+// OnRetryAfterError sets a function that is called with a [RetryAfterError]
+// whenever a retryable response contains a malformed "Retry-After" (or
+// "RateLimit-Reset") header.
+//
+// This can be used to report broken upstreams to their operators. If the
+// function returns a non-nil error, the request will not be retried and the
+// error is returned from [Client.Do]. Otherwise, the request is retried using
+// the configured backoff.
+func OnRetryAfterError(fn ErrorFunc) OptionFunc {
+	return func(o *options) { o.onRetryAfterError = fn }
+}
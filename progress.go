@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import "io"
+
+// ProgressFunc is called with the number of bytes transferred so far, and the
+// total number of bytes to transfer, or -1 if the total is unknown.
+type ProgressFunc func(transferred, total int64)
+
+// progressBodyFunc wraps a [BodyFunc] so every body it returns reports its
+// progress to fn, starting from zero.
+func progressBodyFunc(body BodyFunc, total int64, fn ProgressFunc) BodyFunc {
+	return func() (io.ReadCloser, error) {
+		r, err := body()
+		if err != nil {
+			return nil, err
+		}
+		return newProgressReadCloser(r, total, fn), nil
+	}
+}
+
+// withUploadProgress returns a copy of req whose body reports its progress to
+// the upload progress function, if configured. This must be done after the
+// request is authenticated and signed, as either may read the body, which
+// would otherwise be reported as progress.
+func (o *requestOptions) withUploadProgress(req *Request) *Request {
+	if o.uploadProgress == nil || req.body == nil {
+		return req
+	}
+	req = req.clone()
+	req.setBodyFunc(progressBodyFunc(req.body, req.ContentLength, o.uploadProgress), req.ContentLength)
+	return req
+}
+
+// progressReadCloser is an [io.ReadCloser] that reports the number of bytes
+// read from it.
+type progressReadCloser struct {
+	io.ReadCloser
+
+	fn    ProgressFunc
+	n     int64
+	total int64
+}
+
+// newProgressReadCloser returns a new [progressReadCloser], reporting that no
+// bytes have been read yet.
+func newProgressReadCloser(r io.ReadCloser, total int64, fn ProgressFunc) *progressReadCloser {
+	fn(0, total)
+	return &progressReadCloser{ReadCloser: r, fn: fn, total: total}
+}
+
+// Read reads from the underlying [io.ReadCloser], reporting any bytes read.
+func (r *progressReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.n += int64(n)
+		r.fn(r.n, r.total)
+	}
+	return n, err
+}
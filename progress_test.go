@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+// progressRecorder records every call to a [nxhttp.ProgressFunc].
+type progressRecorder struct {
+	mu    sync.Mutex
+	calls [][2]int64
+}
+
+func (p *progressRecorder) record(transferred, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, [2]int64{transferred, total})
+}
+
+// resets returns the number of times the progress was reset to zero.
+func (p *progressRecorder) resets() int {
+	var n int
+	for _, c := range p.calls {
+		if c[0] == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestProgress(t *testing.T) {
+	body := strings.Repeat("a", 64*1024)
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if n.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = io.WriteString(w, body)
+	}))
+	defer ts.Close()
+
+	var upload, download progressRecorder
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPut, ts.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(
+		req,
+		nxhttp.WithUploadProgress(upload.record),
+		nxhttp.WithResponseProgress(download.record),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Close()
+
+	size := int64(len(body))
+	for name, p := range map[string]*progressRecorder{"upload": &upload, "download": &download} {
+		if got := p.resets(); got != 2 {
+			t.Errorf("%s: expected progress to start from zero 2 times, but got %d", name, got)
+		}
+		if last := p.calls[len(p.calls)-1]; last != [2]int64{size, size} {
+			t.Errorf("%s: expected final progress to be %d/%d, but got %d/%d", name, size, size, last[0], last[1])
+		}
+	}
+}
+
+func TestUploadProgress_Signed(t *testing.T) {
+	body := strings.Repeat("a", 64*1024)
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer ts.Close()
+
+	// The signer reads the body to generate its digest, which must not be
+	// reported as progress.
+	var upload progressRecorder
+	signer := &nxhttp.MessageSigner{Key: nxhttp.HMACKey([]byte("secret")), ContentDigest: true}
+	c := nxhttp.FromClient(ts.Client())
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPut, ts.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req, nxhttp.WithRequestSigner(signer), nxhttp.WithUploadProgress(upload.record))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Close()
+
+	if got := upload.resets(); got != 1 {
+		t.Errorf("expected progress to start from zero 1 time, but got %d", got)
+	}
+	size := int64(len(body))
+	if last := upload.calls[len(upload.calls)-1]; last != [2]int64{size, size} {
+		t.Errorf("expected final progress to be %d/%d, but got %d/%d", size, size, last[0], last[1])
+	}
+}
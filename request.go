@@ -35,15 +35,32 @@ func NewRequest(ctx context.Context, method, url string, body any) (*Request, er
 	return req, nil
 }
 
-// SetBody sets the body on the [Request].
-func (r *Request) SetBody(v any) (err error) {
-	r.body, r.ContentLength, err = GetBody(v)
+// setBodyFunc sets the body on the [Request] using an existing [BodyFunc] and
+// size.
+func (r *Request) setBodyFunc(fn BodyFunc, n int64) {
+	r.body, r.ContentLength = fn, n
 	if r.body == nil {
 		r.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
 	} else {
 		r.GetBody = r.body
 	}
-	return err
+}
+
+// SetBody sets the body on the [Request].
+//
+// If v has a `ContentType() string` method (such as [JSONBody]), the
+// "Content-Type" header of the request is set using it, unless the header
+// was already set.
+func (r *Request) SetBody(v any) error {
+	fn, n, err := GetBody(v)
+	r.setBodyFunc(fn, n)
+	if err != nil {
+		return err
+	}
+	if ct, ok := v.(interface{ ContentType() string }); ok && httpheader.Get(r.Header, httpheader.ContentType) == "" {
+		r.SetHeader(httpheader.ContentType, ct.ContentType())
+	}
+	return nil
 }
 
 // WithContext returns wrapped Request with a shallow copy of r with its context
@@ -55,6 +72,15 @@ func (r *Request) WithContext(ctx context.Context) *Request {
 	}
 }
 
+// clone returns a shallow copy of r with a deep copy of its headers, allowing
+// the headers and body of the copy to be modified without affecting r.
+func (r *Request) clone() *Request {
+	return &Request{
+		Request: r.Request.Clone(r.Context()),
+		body:    r.body,
+	}
+}
+
 // AddHeader is like [http.Header.Add], but the key must already be in
 // [httpheader.Canonicalize] form.
 func (r *Request) AddHeader(key httpheader.Key, value string) {
@@ -3,12 +3,97 @@
 
 package nxhttp
 
-import "net/http"
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/matthewpi/nxretry"
+)
 
 // requestOptions represent the options for a [Request].
 type requestOptions struct {
 	transport    func(t *http.Transport)
 	roundTripper func(http.RoundTripper) http.RoundTripper
+
+	//
+	// nxretry
+	//
+	// These options are inherited from the [Client], see [options] for their
+	// documentation.
+	//
+
+	backoff       nxretry.Backoff
+	maxAttempts   uint
+	minRetryAfter time.Duration
+	maxRetryAfter time.Duration
+	retryPolicy   RetryPolicy
+
+	//
+	// response body
+	//
+	// These options are inherited from the [Client], see [options] for their
+	// documentation.
+	//
+
+	maxResponseBytes int64
+	bodyReadTimeout  time.Duration
+	bodyIdleTimeout  time.Duration
+	verifyDigests    bool
+
+	// authenticator adds credentials to every attempt of the request.
+	authenticator Authenticator
+	// signer signs every attempt of the request.
+	signer RequestSigner
+	// responseVerifier verifies the signature of every response.
+	responseVerifier *MessageVerifier
+
+	// compressor compresses the body of the request, if set.
+	compressor *compressor
+
+	// expectStatus is the set of status codes expected in the final
+	// response, if empty, any successful (2xx) status code is expected.
+	expectStatus []int
+
+	// contentDigest are the algorithms used to generate a "Content-Digest"
+	// header for the body of the request.
+	contentDigest []DigestAlgorithm
+
+	// uploadProgress is called as the body of the request is sent.
+	uploadProgress ProgressFunc
+	// responseProgress is called as the body of the response is read.
+	responseProgress ProgressFunc
+
+	// notModified indicates a "304 Not Modified" response is expected, as
+	// the request is conditional.
+	notModified bool
+	// expectSuccess indicates any unsuccessful status code is unexpected,
+	// unless status codes were set using [ExpectStatus].
+	expectSuccess bool
+
+	// retryNonIdempotent allows a non-idempotent request to be retried even
+	// if it doesn't have an "Idempotency-Key" header.
+	retryNonIdempotent bool
+}
+
+// requestOptions returns a new [requestOptions] instance, inheriting any
+// options that can be overridden per-request from the client's [options].
+func (o *options) requestOptions() *requestOptions {
+	return &requestOptions{
+		backoff:       o.backoff,
+		maxAttempts:   o.maxAttempts,
+		minRetryAfter: o.minRetryAfter,
+		maxRetryAfter: o.maxRetryAfter,
+		retryPolicy:   o.retryPolicy,
+
+		maxResponseBytes: o.maxResponseBytes,
+		bodyReadTimeout:  o.bodyReadTimeout,
+		bodyIdleTimeout:  o.bodyIdleTimeout,
+		verifyDigests:    o.verifyDigests,
+
+		authenticator: o.authenticator,
+		signer:        o.signer,
+	}
 }
 
 // RequestOption for an [Request].
@@ -39,3 +124,213 @@ func WithRequestTransport(fn func(t *http.Transport)) RequestOptionFunc {
 func WithRequestRoundTripper(fn func(http.RoundTripper) http.RoundTripper) RequestOptionFunc {
 	return func(o *requestOptions) { o.roundTripper = fn }
 }
+
+// WithRequestBackoff overrides the [nxretry.Backoff] used to control the delay
+// between attempts for an individual request. If b is nil, the client's
+// backoff is used.
+func WithRequestBackoff(b nxretry.Backoff) RequestOptionFunc {
+	return func(o *requestOptions) {
+		if b != nil {
+			o.backoff = b
+		}
+	}
+}
+
+// WithRequestMaxAttempts overrides the maximum number of attempts for an
+// individual request. If set to 0 the maximum number of attempts will be
+// unlimited.
+//
+// See [MaxAttempts] for more details.
+func WithRequestMaxAttempts(maxAttempts uint) RequestOptionFunc {
+	return func(o *requestOptions) { o.maxAttempts = maxAttempts }
+}
+
+// WithRequestMinRetryAfter overrides the minimum "Retry-After" duration that
+// will be respected for an individual request.
+//
+// See [MinRetryAfter] for more details.
+func WithRequestMinRetryAfter(d time.Duration) RequestOptionFunc {
+	return func(o *requestOptions) { o.minRetryAfter = d }
+}
+
+// WithRequestMaxRetryAfter overrides the maximum "Retry-After" duration that
+// will be respected for an individual request.
+//
+// See [MaxRetryAfter] for more details.
+func WithRequestMaxRetryAfter(d time.Duration) RequestOptionFunc {
+	return func(o *requestOptions) { o.maxRetryAfter = d }
+}
+
+// WithRequestRetryPolicy overrides the [RetryPolicy] for an individual
+// request. If p is nil, the client's retry policy is used.
+func WithRequestRetryPolicy(p RetryPolicy) RequestOptionFunc {
+	return func(o *requestOptions) {
+		if p != nil {
+			o.retryPolicy = p
+		}
+	}
+}
+
+// WithRequestMaxResponseBytes overrides the maximum size of the response body
+// for an individual request.
+//
+// See [MaxResponseBytes] for more details.
+func WithRequestMaxResponseBytes(n int64) RequestOptionFunc {
+	return func(o *requestOptions) { o.maxResponseBytes = n }
+}
+
+// WithRequestBodyReadTimeout overrides the timeout for reading the response
+// body for an individual request.
+//
+// See [BodyReadTimeout] for more details.
+func WithRequestBodyReadTimeout(d time.Duration) RequestOptionFunc {
+	return func(o *requestOptions) { o.bodyReadTimeout = d }
+}
+
+// WithRequestBodyIdleTimeout overrides the idle timeout for reading the
+// response body for an individual request.
+//
+// See [BodyIdleTimeout] for more details.
+func WithRequestBodyIdleTimeout(d time.Duration) RequestOptionFunc {
+	return func(o *requestOptions) { o.bodyIdleTimeout = d }
+}
+
+// WithRequestVerifyDigests overrides whether the response body is verified
+// against its digest headers for an individual request.
+//
+// See [VerifyDigests] for more details.
+func WithRequestVerifyDigests(verify bool) RequestOptionFunc {
+	return func(o *requestOptions) { o.verifyDigests = verify }
+}
+
+// WithRequestAuthenticator overrides the [Authenticator] for an individual
+// request. If a is nil, the request is not authenticated.
+//
+// See [WithAuthenticator] for more details.
+func WithRequestAuthenticator(a Authenticator) RequestOptionFunc {
+	return func(o *requestOptions) { o.authenticator = a }
+}
+
+// WithRequestSigner overrides the [RequestSigner] for an individual request. If
+// s is nil, the request is not signed.
+//
+// See [WithSigner] for more details.
+func WithRequestSigner(s RequestSigner) RequestOptionFunc {
+	return func(o *requestOptions) { o.signer = s }
+}
+
+// VerifyResponseSignature verifies the HTTP message signature of the response
+// to every attempt of a request using v, see [MessageVerifier.VerifyResponse].
+//
+// If verification fails, the attempt fails with a [SignatureError].
+func VerifyResponseSignature(v *MessageVerifier) RequestOptionFunc {
+	return func(o *requestOptions) { o.responseVerifier = v }
+}
+
+// RetryNonIdempotent allows a request using a non-idempotent method (such as
+// POST or PATCH) to be retried even if it does not carry an "Idempotency-Key"
+// header.
+//
+// Only use this option if the server is known to handle duplicate requests
+// safely, otherwise retrying a request may cause it to be processed multiple
+// times.
+func RetryNonIdempotent() RequestOptionFunc {
+	return func(o *requestOptions) { o.retryNonIdempotent = true }
+}
+
+// ExpectStatus sets the status codes that are expected in the final response
+// of a request.
+//
+// If the final response has any other status code, [Client.Do] returns a
+// [StatusError] containing the start of the response body, and the response
+// is closed. Responses with an expected status code are never retried, even
+// if they would be by the [RetryPolicy].
+func ExpectStatus(codes ...int) RequestOptionFunc {
+	codes = slices.Clone(codes)
+	return func(o *requestOptions) { o.expectStatus = codes }
+}
+
+// allowNotModified marks a "304 Not Modified" response as expected, used by
+// conditional requests made by the cache.
+func allowNotModified() RequestOptionFunc {
+	return func(o *requestOptions) { o.notModified = true }
+}
+
+// expectSuccess marks any unsuccessful status code as unexpected, unless
+// status codes were set using [ExpectStatus], used by the JSON helpers.
+func expectSuccess() RequestOptionFunc {
+	return func(o *requestOptions) { o.expectSuccess = true }
+}
+
+// isExpected reports whether a response with the given status code is
+// considered successful for the request.
+func (o *requestOptions) isExpected(code int) bool {
+	if code >= http.StatusOK && code <= 299 {
+		return true
+	}
+	if o.notModified && code == http.StatusNotModified {
+		return true
+	}
+	return slices.Contains(o.expectStatus, code)
+}
+
+// isUnexpected reports whether the caller configured the status codes they
+// expect (see [ExpectStatus]), and the given status code isn't one of them. If
+// none were configured, unsuccessful status codes are only unexpected if
+// [expectSuccess] was used.
+func (o *requestOptions) isUnexpected(code int) bool {
+	if len(o.expectStatus) == 0 {
+		return o.expectSuccess && !o.isExpected(code)
+	}
+	if slices.Contains(o.expectStatus, code) {
+		return false
+	}
+	return !o.notModified || code != http.StatusNotModified
+}
+
+// CompressBody compresses the body of a request using the given encoding and
+// sets the "Content-Encoding" header accordingly.
+//
+// Bodies smaller than minSize are not compressed. Bodies up to 1 MiB are
+// compressed once ahead of time so the exact ContentLength is known, while
+// larger bodies (or bodies of an unknown size) are compressed as they are
+// sent on every attempt using chunked encoding.
+//
+// Requests that already have a "Content-Encoding" header are not compressed.
+func CompressBody(enc Encoding, minSize int64) RequestOptionFunc {
+	return func(o *requestOptions) {
+		o.compressor = &compressor{encoding: enc, minSize: minSize}
+	}
+}
+
+// WithUploadProgress sets a function that is called as the body of a request
+// is sent, with the total being the ContentLength of the request.
+//
+// The progress is reported from zero at the start of every attempt, so a
+// retried request will report its progress again from the beginning. If the
+// body is also compressed, the progress is of the compressed body.
+func WithUploadProgress(fn ProgressFunc) RequestOptionFunc {
+	return func(o *requestOptions) { o.uploadProgress = fn }
+}
+
+// WithResponseProgress sets a function that is called as the body of a
+// response is read, with the total being the "Content-Length" of the response.
+//
+// Like [WithUploadProgress], the progress is reported from zero for the
+// response of every attempt. If the response is decompressed, the progress is
+// of the compressed body as it was received.
+func WithResponseProgress(fn ProgressFunc) RequestOptionFunc {
+	return func(o *requestOptions) { o.responseProgress = fn }
+}
+
+// WithContentDigest sets the "Content-Digest" header (RFC 9530) of a request
+// to the digest of its body using each of the given algorithms.
+//
+// The digest is calculated once by reading the entire body before the request
+// is sent. If the body is also compressed, the digest is of the compressed
+// body. Requests without a body, or that already have a "Content-Digest"
+// header, are left unchanged.
+func WithContentDigest(algs ...DigestAlgorithm) RequestOptionFunc {
+	algs = slices.Clone(algs)
+	return func(o *requestOptions) { o.contentDigest = algs }
+}
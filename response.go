@@ -4,8 +4,10 @@
 package nxhttp
 
 import (
+	"context"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/matthewpi/nxhttp/httpheader"
@@ -18,10 +20,42 @@ import (
 // is read.
 type Response struct {
 	*http.Response
+
+	// attempts it took to receive the response.
+	attempts uint
+	// elapsed time across all attempts.
+	elapsed time.Duration
+	// fromCache indicates the response was served from the cache.
+	fromCache bool
+	// discard wraps the original body of the response, if any.
+	discard *discardReadCloser
+	// maxBytes is the maximum size of the body set using [MaxResponseBytes],
+	// or 0 if it is unlimited.
+	maxBytes int64
 }
 
 var _ io.Closer = (*Response)(nil)
 
+// Attempts returns the number of attempts it took to receive the response.
+func (r *Response) Attempts() uint {
+	return r.attempts
+}
+
+// Elapsed returns the total time spent across all attempts, including any
+// delays between them, until the response headers were received.
+func (r *Response) Elapsed() time.Duration {
+	return r.elapsed
+}
+
+// FromCache reports whether the response was served from the cache without
+// being sent by the server, see [WithCache].
+//
+// A stored response that was successfully revalidated with the server is also
+// considered to be served from the cache.
+func (r *Response) FromCache() bool {
+	return r.fromCache
+}
+
 // Closes the body of r.
 func (r *Response) Close() error {
 	// If the body is somehow nil, do nothing.
@@ -35,19 +69,66 @@ func (r *Response) Close() error {
 	return r.Body.Close()
 }
 
+// abort closes the response like [Response.Close], but without discarding the
+// rest of the body first. This is used for bodies that may never end, such as
+// an event stream, where discarding could block until the server sends more
+// data.
+func (r *Response) abort() error {
+	if r.discard != nil {
+		r.discard.skip = true
+	}
+	return r.Close()
+}
+
 // GetHeader is like [http.Header.Get], but the key must already be in
 // [httpheader.Key] form.
 func (r *Response) GetHeader(key httpheader.Key) string {
 	return httpheader.Get(r.Header, key)
 }
 
+// hasBody reports whether the response may have a body. Responses to HEAD
+// requests, and responses with a 1xx, "204 No Content" or "304 Not Modified"
+// status code never have one, even if their headers describe one.
+func (r *Response) hasBody() bool {
+	if r.Body == nil || (r.Request != nil && r.Request.Method == http.MethodHead) {
+		return false
+	}
+	switch {
+	case r.StatusCode >= 100 && r.StatusCode < 200,
+		r.StatusCode == http.StatusNoContent,
+		r.StatusCode == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
 // retryAfter parses the "Retry-After" header and returns it as a
 // [time.Duration].
 //
 // The value of the header could be either an HTTP Date (see [http.ParseTime])
 // or a number of seconds.
+//
+// If the response has no "Retry-After" header, the "RateLimit-Reset" and
+// "X-RateLimit-Reset" headers are used instead (in that order).
+//
+// If the header is malformed, a [RetryAfterError] is returned.
 func (r *Response) retryAfter() (time.Duration, error) {
-	return httpheader.ParseRetryAfter(httpheader.Get(r.Header, httpheader.RetryAfter))
+	key, parse := httpheader.RetryAfter, httpheader.ParseRetryAfter
+	v := httpheader.Get(r.Header, key)
+	if v == "" {
+		parse = httpheader.ParseRateLimitReset
+		for _, key = range []httpheader.Key{httpheader.RateLimitReset, httpheader.XRateLimitReset} {
+			if v = httpheader.Get(r.Header, key); v != "" {
+				break
+			}
+		}
+	}
+
+	d, err := parse(v)
+	if err != nil {
+		return 0, RetryAfterError{Header: key, Value: v, err: err}
+	}
+	return d, nil
 }
 
 // discardReadCloser wraps an [io.ReadCloser], overriding it's Close method
@@ -57,6 +138,9 @@ type discardReadCloser struct {
 	// eof indicates whether the [io.ReadCloser] we are wrapping has ever
 	// returned an [io.EOF] error.
 	eof bool
+	// skip indicates the rest of the body should not be discarded when it
+	// is closed.
+	skip bool
 }
 
 // Read satisfies [io.Reader].
@@ -70,13 +154,112 @@ func (r *discardReadCloser) Read(p []byte) (int, error) {
 
 // Close satisfies [io.Closer].
 func (r *discardReadCloser) Close() error {
-	if !r.eof {
+	if !r.eof && !r.skip {
 		// Discard the rest of the response body.
 		discard(r.ReadCloser)
 	}
 	return r.ReadCloser.Close()
 }
 
+// limitedReadCloser wraps an [io.ReadCloser], returning a [BodyTooLargeError]
+// if more than a limited amount of data is read from it.
+type limitedReadCloser struct {
+	io.ReadCloser
+	// limit is the maximum number of bytes that can be read.
+	limit int64
+	// n is the number of bytes remaining.
+	n int64
+}
+
+// newLimitedReadCloser returns a new [limitedReadCloser] that allows at most
+// limit bytes to be read from r.
+func newLimitedReadCloser(r io.ReadCloser, limit int64) *limitedReadCloser {
+	return &limitedReadCloser{ReadCloser: r, limit: limit, n: limit}
+}
+
+// Read satisfies [io.Reader].
+func (r *limitedReadCloser) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Allow reading one byte past the limit, so we know if the body is
+	// actually larger than the limit rather than exactly the limit.
+	if int64(len(p)) > r.n+1 {
+		p = p[:r.n+1]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if int64(n) <= r.n {
+		r.n -= int64(n)
+		return n, err
+	}
+	n = int(r.n)
+	r.n = 0
+	return n, BodyTooLargeError{Limit: r.limit}
+}
+
+// timeoutReadCloser wraps an [io.ReadCloser], interrupting any reads once a
+// timeout is reached by cancelling the context of the request.
+type timeoutReadCloser struct {
+	io.ReadCloser
+	// cancel the context of the request.
+	cancel context.CancelFunc
+
+	// idle is the maximum duration between reads that return data.
+	idle time.Duration
+	// total and idle timers, if configured.
+	totalTimer, idleTimer *time.Timer
+
+	// err is the [BodyTimeoutError] for the timeout that was reached, if any.
+	err atomic.Pointer[BodyTimeoutError]
+}
+
+// newTimeoutReadCloser returns a new [timeoutReadCloser] that calls cancel if
+// reading the entire body of r takes longer than total, or if no data is read
+// from r for longer than idle. A zero value disables the respective timeout.
+func newTimeoutReadCloser(r io.ReadCloser, cancel context.CancelFunc, total, idle time.Duration) *timeoutReadCloser {
+	t := &timeoutReadCloser{ReadCloser: r, cancel: cancel, idle: idle}
+	if total > 0 {
+		t.totalTimer = time.AfterFunc(total, func() { t.timeout(BodyTimeoutError{Duration: total}) })
+	}
+	if idle > 0 {
+		t.idleTimer = time.AfterFunc(idle, func() { t.timeout(BodyTimeoutError{Duration: idle, Idle: true}) })
+	}
+	return t
+}
+
+// timeout records err and cancels the request.
+func (r *timeoutReadCloser) timeout(err BodyTimeoutError) {
+	r.err.CompareAndSwap(nil, &err)
+	r.cancel()
+}
+
+// Read satisfies [io.Reader].
+func (r *timeoutReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if tErr := r.err.Load(); tErr != nil && err != nil && err != io.EOF {
+		// Replace the error caused by cancelling the request.
+		return n, *tErr
+	}
+	if n > 0 && r.idleTimer != nil {
+		r.idleTimer.Reset(r.idle)
+	}
+	return n, err
+}
+
+// Close satisfies [io.Closer].
+func (r *timeoutReadCloser) Close() error {
+	if r.totalTimer != nil {
+		r.totalTimer.Stop()
+	}
+	if r.idleTimer != nil {
+		r.idleTimer.Stop()
+	}
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
+
 // discard copies a limited amount of data from an [io.Reader] to [io.Discard].
 func discard(r io.Reader) {
 	// We use an [io.LimitReader] here to protect against misbehaving (or even
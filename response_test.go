@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+func TestMaxResponseBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("a", 1024))
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.MaxResponseBytes(1024))
+	for _, tc := range []struct {
+		opts []nxhttp.RequestOption
+		err  bool
+	}{
+		{nil, false},
+		{[]nxhttp.RequestOption{nxhttp.WithRequestMaxResponseBytes(512)}, true},
+	} {
+		req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Do(req, tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		_ = res.Close()
+
+		var tErr nxhttp.BodyTooLargeError
+		if tc.err {
+			if !errors.As(err, &tErr) || tErr.Limit != 512 || len(b) != 512 {
+				t.Errorf("expected a BodyTooLargeError after 512 bytes, but got %v after %d bytes", err, len(b))
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestBodyIdleTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "partial")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.BodyIdleTimeout(50*time.Millisecond))
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+
+	b, err := io.ReadAll(res.Body)
+	var tErr nxhttp.BodyTimeoutError
+	if !errors.As(err, &tErr) || !tErr.Idle {
+		t.Fatalf("expected an idle BodyTimeoutError, but got %v", err)
+	}
+	if string(b) != "partial" {
+		t.Errorf("expected to read %q, but got %q", "partial", b)
+	}
+}
+
+func TestBodyReadTimeout(t *testing.T) {
+	// Send the body slowly but steadily, so only the timeout for reading the
+	// entire body can be reached.
+	const body = "abcdefghijklmnopqrst"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := range len(body) {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			_, _ = io.WriteString(w, body[i:i+1])
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(
+		ts.Client(),
+		nxhttp.BodyReadTimeout(100*time.Millisecond),
+		nxhttp.BodyIdleTimeout(time.Second),
+	)
+	for i, tc := range []struct {
+		opts    []nxhttp.RequestOption
+		timeout time.Duration
+	}{
+		{timeout: 100 * time.Millisecond},
+		{opts: []nxhttp.RequestOption{nxhttp.WithRequestBodyReadTimeout(50 * time.Millisecond)}, timeout: 50 * time.Millisecond},
+		{opts: []nxhttp.RequestOption{nxhttp.WithRequestBodyReadTimeout(5 * time.Second)}},
+	} {
+		req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Do(req, tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		_ = res.Close()
+
+		if tc.timeout == 0 {
+			if err != nil {
+				t.Errorf("#%d: unexpected error: %v", i, err)
+			} else if string(b) != body {
+				t.Errorf("#%d: expected to read %q, but got %q", i, body, b)
+			}
+			continue
+		}
+		var tErr nxhttp.BodyTimeoutError
+		if !errors.As(err, &tErr) || tErr.Idle || tErr.Duration != tc.timeout {
+			t.Errorf("#%d: expected a BodyTimeoutError after %s, but got %v", i, tc.timeout, err)
+		}
+		if len(b) == 0 || len(b) == len(body) {
+			t.Errorf("#%d: expected to read part of the body, but got %q", i, b)
+		}
+	}
+}
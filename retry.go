@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+	"github.com/matthewpi/nxretry"
+)
+
+// Attempt describes the outcome of a single attempt made by [Client.Do].
+type Attempt struct {
+	// Request that was sent.
+	Request *Request
+
+	// Number of the attempt, starting at 1.
+	Number uint
+
+	// StatusCode of the response, or 0 if the attempt failed before a
+	// response was received.
+	StatusCode int
+
+	// Err that caused the attempt to fail, if any.
+	//
+	// For attempts that received a response, Err is a [RetryAfterError] if
+	// the response contained a malformed "Retry-After" header.
+	Err error
+
+	// Retry reports whether the request will be retried after this attempt,
+	// provided the request's context isn't done before the next attempt.
+	Retry bool
+
+	// RetryAfter is the delay before the next attempt as requested by the
+	// server using the "Retry-After" (or "RateLimit-Reset") header, after
+	// being limited by [MinRetryAfter] and [MaxRetryAfter].
+	//
+	// A zero value indicates the server didn't request a delay (or it was
+	// ignored), in which case the configured [nxretry.Backoff] is used.
+	RetryAfter time.Duration
+
+	// Delay before the next attempt, either RetryAfter if the server
+	// requested a delay, or the delay from the configured [nxretry.Backoff].
+	// It is zero if the request will not be retried.
+	Delay time.Duration
+
+	// Elapsed time since the first attempt was started.
+	Elapsed time.Duration
+}
+
+// AttemptFunc is called by [Client.Do] after every attempt.
+//
+// See [OnAttempt] for more details.
+type AttemptFunc func(context.Context, Attempt)
+
+// RetryPolicy reports whether a failed [Attempt] should be retried.
+//
+// The Retry, RetryAfter, Delay and Elapsed fields of the [Attempt] are not yet
+// populated when it is passed to a RetryPolicy.
+//
+// A RetryPolicy is only consulted for attempts that failed, either due to an
+// error or a response with a non-2xx status code. Whether another attempt is
+// actually made is still limited by [MaxAttempts] and the request's context.
+//
+// Requests using a non-idempotent method (such as POST or PATCH) are never
+// retried unless they carry an "Idempotency-Key" header or were sent with
+// [RetryNonIdempotent], regardless of what the RetryPolicy returns.
+type RetryPolicy func(ctx context.Context, a Attempt) bool
+
+// backoffDelayer is implemented by an [nxretry.Backoff] that can report the
+// delay before an attempt ahead of time.
+type backoffDelayer interface {
+	Next(attempt uint) time.Duration
+}
+
+// backoffDelay returns the delay from b after the given attempt, or 0 if b
+// can't report it ahead of time.
+func backoffDelay(b nxretry.Backoff, attempt uint) time.Duration {
+	if d, ok := b.(backoffDelayer); ok {
+		return d.Next(attempt)
+	}
+	return 0
+}
+
+// DefaultRetryStatusCodes returns the status codes that are retried by
+// [DefaultRetryPolicy].
+func DefaultRetryStatusCodes() []int {
+	return []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+}
+
+// DefaultRetryPolicy is the [RetryPolicy] used by a [Client] unless one is
+// configured using [WithRetryPolicy].
+//
+// It retries timeouts and responses with any of the status codes returned by
+// [DefaultRetryStatusCodes].
+func DefaultRetryPolicy(ctx context.Context, a Attempt) bool {
+	return defaultRetryPolicy(ctx, a)
+}
+
+// defaultRetryPolicy backs [DefaultRetryPolicy] so the status codes only need
+// to be allocated once.
+var defaultRetryPolicy = RetryOnStatus(DefaultRetryStatusCodes()...)
+
+// RetryOnStatus returns a [RetryPolicy] that retries timeouts and responses
+// with any of the given status codes.
+//
+// To extend the default behaviour rather than replace it, combine the codes
+// with [DefaultRetryStatusCodes].
+//
+//	nxhttp.RetryOnStatus(append(nxhttp.DefaultRetryStatusCodes(), 409, 520, 521, 522, 523, 524)...)
+func RetryOnStatus(codes ...int) RetryPolicy {
+	codes = slices.Clone(codes)
+	return func(_ context.Context, a Attempt) bool {
+		if a.StatusCode == 0 {
+			// Only retry here if the error is retryable. We don't want to keep
+			// retrying a broken request such as one with a malformed URL, but
+			// we do for a connection timeout (as an example).
+			return a.Err != nil && isTimeout(a.Err)
+		}
+		return slices.Contains(codes, a.StatusCode)
+	}
+}
+
+// isIdempotent reports whether method is idempotent and therefore safe to be
+// retried without any additional safeguards.
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isReplayable reports whether req can be safely sent more than once.
+//
+// Idempotent methods are always replayable, while any other method (such as
+// POST or PATCH) is only replayable if the request carries an
+// "Idempotency-Key" header, allowing the server to deduplicate it.
+func isReplayable(req *Request) bool {
+	if isIdempotent(req.Method) {
+		return true
+	}
+	return httpheader.Get(req.Header, httpheader.IdempotencyKey) != ""
+}
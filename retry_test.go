@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+	"github.com/matthewpi/nxhttp/httpheader"
+	"github.com/matthewpi/nxretry"
+)
+
+func TestRetryOnStatus(t *testing.T) {
+	ctx := context.Background()
+	p := nxhttp.RetryOnStatus(append(nxhttp.DefaultRetryStatusCodes(), http.StatusConflict)...)
+	for i, tc := range []struct {
+		a  nxhttp.Attempt
+		ok bool
+	}{
+		{nxhttp.Attempt{StatusCode: http.StatusConflict}, true},
+		{nxhttp.Attempt{StatusCode: http.StatusServiceUnavailable}, true},
+		{nxhttp.Attempt{StatusCode: http.StatusNotFound}, false},
+		{nxhttp.Attempt{Err: os.ErrDeadlineExceeded}, true},
+		{nxhttp.Attempt{Err: errors.New("malformed")}, false},
+	} {
+		if got := p(ctx, tc.a); got != tc.ok {
+			t.Errorf("#%d: expected %t, but got %t", i, tc.ok, got)
+		}
+	}
+
+	if nxhttp.DefaultRetryPolicy(ctx, nxhttp.Attempt{StatusCode: http.StatusConflict}) {
+		t.Error("DefaultRetryPolicy should not retry a 409 status code")
+	}
+}
+
+// newRetryTestServer returns a server that responds with status for every
+// request, and a pointer to the number of requests it received.
+func newRetryTestServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &n
+}
+
+// fastBackoff is a [nxretry.Backoff] that keeps tests fast.
+func fastBackoff() nxretry.Backoff {
+	return &nxretry.Exponential{Factor: 1, Min: time.Millisecond, Max: time.Millisecond}
+}
+
+func TestClient_Do_Retry(t *testing.T) {
+	ctx := context.Background()
+	ts, n := newRetryTestServer(t, http.StatusConflict)
+	c := nxhttp.FromClient(
+		ts.Client(),
+		nxhttp.WithBackoff(fastBackoff()),
+		nxhttp.WithRetryPolicy(nxhttp.RetryOnStatus(http.StatusConflict)),
+	)
+
+	for i, tc := range []struct {
+		method   string
+		header   bool
+		opts     []nxhttp.RequestOption
+		attempts int32
+	}{
+		{method: http.MethodGet, attempts: 3},
+		{method: http.MethodGet, opts: []nxhttp.RequestOption{nxhttp.WithRequestMaxAttempts(5)}, attempts: 5},
+		{method: http.MethodGet, opts: []nxhttp.RequestOption{nxhttp.WithRequestRetryPolicy(nil)}, attempts: 3},
+		{method: http.MethodGet, opts: []nxhttp.RequestOption{nxhttp.WithRequestRetryPolicy(nxhttp.DefaultRetryPolicy)}, attempts: 1},
+		{method: http.MethodPost, attempts: 1},
+		{method: http.MethodPost, header: true, attempts: 3},
+		{method: http.MethodPost, opts: []nxhttp.RequestOption{nxhttp.RetryNonIdempotent()}, attempts: 3},
+	} {
+		n.Store(0)
+		req, err := nxhttp.NewRequest(ctx, tc.method, ts.URL, "body")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.header {
+			req.SetHeader(httpheader.IdempotencyKey, "key")
+		}
+		res, err := c.Do(req, tc.opts...)
+		if err == nil {
+			_ = res.Close()
+		}
+		if got := n.Load(); got != tc.attempts {
+			t.Errorf("#%d: expected %d attempts, but got %d", i, tc.attempts, got)
+		}
+	}
+}
+
+func TestGenerateIdempotencyKey(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()), nxhttp.GenerateIdempotencyKey())
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.Do(req); err == nil {
+		_ = res.Close()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 attempts, but got %d", len(keys))
+	}
+	if keys[0] == "" || keys[0] != keys[1] || keys[1] != keys[2] {
+		t.Errorf("expected all attempts to share a single key, but got %q", keys)
+	}
+	if v := req.Header.Get("Idempotency-Key"); v != "" {
+		t.Errorf("expected the caller's request to be left unchanged, but got key %q", v)
+	}
+}
+
+func TestOnAttempt(t *testing.T) {
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		switch n.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+
+	var attempts []nxhttp.Attempt
+	c := nxhttp.FromClient(
+		ts.Client(),
+		nxhttp.WithBackoff(fastBackoff()),
+		nxhttp.MinRetryAfter(0),
+		nxhttp.MaxRetryAfter(10*time.Millisecond),
+		nxhttp.OnAttempt(func(_ context.Context, a nxhttp.Attempt) {
+			attempts = append(attempts, a)
+		}),
+	)
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Close()
+
+	if res.Attempts() != 3 {
+		t.Errorf("expected Response.Attempts() to return 3, but got %d", res.Attempts())
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("expected OnAttempt to be called 3 times, but got %d", len(attempts))
+	}
+	for i, a := range attempts {
+		if a.Number != uint(i+1) {
+			t.Errorf("#%d: expected attempt number %d, but got %d", i, i+1, a.Number)
+		}
+		status := http.StatusBadGateway
+		if i == 2 {
+			status = http.StatusOK
+		}
+		if a.StatusCode != status {
+			t.Errorf("#%d: expected status code %d, but got %d", i, status, a.StatusCode)
+		}
+		if retry := i < 2; a.Retry != retry {
+			t.Errorf("#%d: expected Retry to be %t, but got %t", i, retry, a.Retry)
+		}
+		var retryAfter time.Duration
+		if i == 0 {
+			retryAfter = 10 * time.Millisecond
+		}
+		if a.RetryAfter != retryAfter {
+			t.Errorf("#%d: expected RetryAfter to be %s, but got %s", i, retryAfter, a.RetryAfter)
+		}
+		// The first delay was requested by the server, while the second is
+		// from the backoff.
+		delay := []time.Duration{retryAfter, time.Millisecond, 0}[i]
+		if a.Delay != delay {
+			t.Errorf("#%d: expected Delay to be %s, but got %s", i, delay, a.Delay)
+		}
+	}
+}
+
+func TestOnRetryAfterError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "soon")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	var got []nxhttp.RetryAfterError
+	c := nxhttp.FromClient(
+		ts.Client(),
+		nxhttp.WithBackoff(fastBackoff()),
+		nxhttp.OnRetryAfterError(func(_ context.Context, err error) error {
+			var raErr nxhttp.RetryAfterError
+			if !errors.As(err, &raErr) {
+				t.Errorf("expected a RetryAfterError, but got %T", err)
+			}
+			got = append(got, raErr)
+			return nil
+		}),
+	)
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.Do(req); err == nil {
+		_ = res.Close()
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("expected OnRetryAfterError to be called 3 times, but got %d", len(got))
+	}
+	if got[0].Header != httpheader.RetryAfter || got[0].Value != "soon" {
+		t.Errorf("unexpected RetryAfterError: %v", got[0])
+	}
+}
+
+func TestRetryExhaustedError(t *testing.T) {
+	ts, _ := newRetryTestServer(t, http.StatusServiceUnavailable)
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req)
+	if err == nil {
+		_ = res.Close()
+		t.Fatal("expected an error")
+	}
+
+	var rErr nxhttp.RetryExhaustedError
+	if !errors.As(err, &rErr) {
+		t.Fatalf("expected a RetryExhaustedError, but got %T", err)
+	}
+	if len(rErr.Attempts) != 3 {
+		t.Errorf("expected 3 attempts, but got %d", len(rErr.Attempts))
+	}
+	if sErr, ok := nxhttp.AsStatusError(err); !ok || sErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected a StatusError with a %d status code, but got %v", http.StatusServiceUnavailable, err)
+	}
+	if expected := "nxhttp: giving up after 3 attempts: 503, 503, 503"; err.Error() != expected {
+		t.Errorf("expected %q, but got %q", expected, err.Error())
+	}
+}
+
+func TestRetryExhaustedError_Context(t *testing.T) {
+	ts, _ := newRetryTestServer(t, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel the context once the first attempt fails, while the client is
+	// waiting to retry it.
+	c := nxhttp.FromClient(
+		ts.Client(),
+		nxhttp.OnAttempt(func(context.Context, nxhttp.Attempt) { cancel() }),
+	)
+	req, err := nxhttp.NewRequest(ctx, http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req)
+	if err == nil {
+		_ = res.Close()
+		t.Fatal("expected an error")
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the error to match context.Canceled, but got %v", err)
+	}
+	if _, ok := nxhttp.AsStatusError(err); !ok {
+		t.Errorf("expected the error to contain a StatusError, but got %v", err)
+	}
+	if expected := "nxhttp: giving up after 1 attempt: 503 (context canceled)"; err.Error() != expected {
+		t.Errorf("expected %q, but got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,524 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+// RequestSigner signs the requests sent by a [Client].
+//
+// SignRequest is called before every attempt of a request (including any
+// retries) with a copy of the request, so signatures are always fresh.
+type RequestSigner interface {
+	// SignRequest signs req.
+	SignRequest(ctx context.Context, req *Request) error
+}
+
+// SignatureKey is a key used to create and verify HTTP message signatures
+// (RFC 9421).
+type SignatureKey interface {
+	// Algorithm returns the name of the algorithm used by the key, as
+	// registered in the HTTP Signature Algorithms registry.
+	Algorithm() string
+	// Sign returns the signature of base.
+	Sign(base []byte) ([]byte, error)
+	// Verify verifies signature is a valid signature of base.
+	Verify(base, signature []byte) error
+}
+
+// errVerifyOnly is returned when signing using a [SignatureKey] that only
+// contains a public key.
+var errVerifyOnly = errors.New("nxhttp: signature key can only be used for verification")
+
+// errInvalidSignature is returned when a signature is invalid.
+var errInvalidSignature = errors.New("nxhttp: invalid signature")
+
+// ed25519Key is an "ed25519" [SignatureKey].
+type ed25519Key struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// Ed25519Key returns a [SignatureKey] using the "ed25519" algorithm.
+func Ed25519Key(key ed25519.PrivateKey) SignatureKey {
+	return ed25519Key{private: key, public: key.Public().(ed25519.PublicKey)}
+}
+
+// Ed25519PublicKey returns a [SignatureKey] using the "ed25519" algorithm that
+// can only be used to verify signatures.
+func Ed25519PublicKey(key ed25519.PublicKey) SignatureKey {
+	return ed25519Key{public: key}
+}
+
+func (ed25519Key) Algorithm() string { return "ed25519" }
+
+func (k ed25519Key) Sign(base []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, errVerifyOnly
+	}
+	return ed25519.Sign(k.private, base), nil
+}
+
+func (k ed25519Key) Verify(base, signature []byte) error {
+	if !ed25519.Verify(k.public, base, signature) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// ecdsaKey is an "ecdsa-p256-sha256" or "ecdsa-p384-sha384" [SignatureKey].
+type ecdsaKey struct {
+	private *ecdsa.PrivateKey
+	public  *ecdsa.PublicKey
+}
+
+// ECDSAKey returns a [SignatureKey] using the "ecdsa-p256-sha256" or
+// "ecdsa-p384-sha384" algorithm, depending on the curve of key.
+func ECDSAKey(key *ecdsa.PrivateKey) SignatureKey {
+	return ecdsaKey{private: key, public: &key.PublicKey}
+}
+
+// ECDSAPublicKey returns a [SignatureKey] using the "ecdsa-p256-sha256" or
+// "ecdsa-p384-sha384" algorithm, depending on the curve of key, that can only
+// be used to verify signatures.
+func ECDSAPublicKey(key *ecdsa.PublicKey) SignatureKey {
+	return ecdsaKey{public: key}
+}
+
+func (k ecdsaKey) Algorithm() string {
+	switch k.public.Curve {
+	case elliptic.P256():
+		return "ecdsa-p256-sha256"
+	case elliptic.P384():
+		return "ecdsa-p384-sha384"
+	default:
+		return ""
+	}
+}
+
+// digest returns the digest of base, and the size of r and s in the
+// signature.
+func (k ecdsaKey) digest(base []byte) ([]byte, int, error) {
+	var h hash.Hash
+	switch k.public.Curve {
+	case elliptic.P256():
+		h = sha256.New()
+	case elliptic.P384():
+		h = sha512.New384()
+	default:
+		return nil, 0, fmt.Errorf("nxhttp: unsupported ECDSA curve '%s'", k.public.Curve.Params().Name)
+	}
+	h.Write(base)
+	return h.Sum(nil), (k.public.Curve.Params().BitSize + 7) / 8, nil
+}
+
+func (k ecdsaKey) Sign(base []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, errVerifyOnly
+	}
+	digest, size, err := k.digest(base)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest)
+	if err != nil {
+		return nil, err
+	}
+	// The signature is the concatenation of r and s, not ASN.1.
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signature, nil
+}
+
+func (k ecdsaKey) Verify(base, signature []byte) error {
+	digest, size, err := k.digest(base)
+	if err != nil {
+		return err
+	}
+	if len(signature) != 2*size {
+		return errInvalidSignature
+	}
+	r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(k.public, digest, r, s) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// rsaPSSKey is an "rsa-pss-sha512" [SignatureKey].
+type rsaPSSKey struct {
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+// rsaPSSOptions are the options used by the "rsa-pss-sha512" algorithm.
+var rsaPSSOptions = &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512}
+
+// RSAPSSKey returns a [SignatureKey] using the "rsa-pss-sha512" algorithm.
+func RSAPSSKey(key *rsa.PrivateKey) SignatureKey {
+	return rsaPSSKey{private: key, public: &key.PublicKey}
+}
+
+// RSAPSSPublicKey returns a [SignatureKey] using the "rsa-pss-sha512"
+// algorithm that can only be used to verify signatures.
+func RSAPSSPublicKey(key *rsa.PublicKey) SignatureKey {
+	return rsaPSSKey{public: key}
+}
+
+func (rsaPSSKey) Algorithm() string { return "rsa-pss-sha512" }
+
+func (k rsaPSSKey) Sign(base []byte) ([]byte, error) {
+	if k.private == nil {
+		return nil, errVerifyOnly
+	}
+	digest := sha512.Sum512(base)
+	return rsa.SignPSS(rand.Reader, k.private, crypto.SHA512, digest[:], rsaPSSOptions)
+}
+
+func (k rsaPSSKey) Verify(base, signature []byte) error {
+	digest := sha512.Sum512(base)
+	if err := rsa.VerifyPSS(k.public, crypto.SHA512, digest[:], signature, rsaPSSOptions); err != nil {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// hmacKey is an "hmac-sha256" [SignatureKey].
+type hmacKey []byte
+
+// HMACKey returns a [SignatureKey] using the "hmac-sha256" algorithm with a
+// shared secret.
+func HMACKey(secret []byte) SignatureKey {
+	return hmacKey(slices.Clone(secret))
+}
+
+func (hmacKey) Algorithm() string { return "hmac-sha256" }
+
+func (k hmacKey) Sign(base []byte) ([]byte, error) {
+	h := hmac.New(sha256.New, k)
+	h.Write(base)
+	return h.Sum(nil), nil
+}
+
+func (k hmacKey) Verify(base, signature []byte) error {
+	expected, _ := k.Sign(base)
+	if !hmac.Equal(expected, signature) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// defaultSignatureLabel is the label used for signatures if none is set.
+const defaultSignatureLabel = "sig1"
+
+// DefaultSignatureComponents returns the components covered by a
+// [MessageSigner] if none are set.
+func DefaultSignatureComponents() []string {
+	return []string{"@method", "@target-uri", "@authority"}
+}
+
+// MessageSigner is a [RequestSigner] that signs requests using HTTP message
+// signatures (RFC 9421), adding the "Signature-Input" and "Signature" headers.
+//
+// As requests are signed before every attempt, the "created" parameter of the
+// signature is always current, even when a request is retried.
+type MessageSigner struct {
+	// Key used to sign requests.
+	Key SignatureKey
+	// KeyID is the value of the "keyid" parameter, if set.
+	KeyID string
+	// Label of the signature, defaults to "sig1".
+	Label string
+	// Components covered by the signature, defaults to
+	// [DefaultSignatureComponents]. Supported derived components are
+	// "@method", "@target-uri", "@authority", "@scheme", "@request-target",
+	// "@path" and "@query", any other component is the lower-case name of a
+	// header, which must be present in the request.
+	Components []string
+	// ContentDigest covers the "Content-Digest" header of requests with a
+	// body. If the request doesn't have the header, a "sha-256" digest is
+	// generated from the body for every attempt, see [WithContentDigest] to
+	// only generate it once.
+	ContentDigest bool
+	// Expires sets the "expires" parameter of the signature to this duration
+	// after it was created, if set.
+	Expires time.Duration
+	// Tag is the value of the "tag" parameter, if set.
+	Tag string
+}
+
+var _ RequestSigner = (*MessageSigner)(nil)
+
+// SignRequest signs req, setting its "Signature-Input" and "Signature"
+// headers.
+func (s *MessageSigner) SignRequest(_ context.Context, req *Request) error {
+	components := s.Components
+	if len(components) == 0 {
+		components = DefaultSignatureComponents()
+	}
+	if s.ContentDigest && req.body != nil {
+		if httpheader.Get(req.Header, httpheader.ContentDigest) == "" {
+			v, err := contentDigest(req.body, []DigestAlgorithm{DigestSHA256})
+			if err != nil {
+				return err
+			}
+			req.SetHeader(httpheader.ContentDigest, v)
+		}
+		if !slices.Contains(components, "content-digest") {
+			components = append(slices.Clip(components), "content-digest")
+		}
+	}
+
+	created := time.Now()
+	var b strings.Builder
+	b.WriteString(formatComponents(components))
+	b.WriteString(";created=" + strconv.FormatInt(created.Unix(), 10))
+	if s.Expires > 0 {
+		b.WriteString(";expires=" + strconv.FormatInt(created.Add(s.Expires).Unix(), 10))
+	}
+	if s.KeyID != "" {
+		b.WriteString(";keyid=" + quoteString(s.KeyID))
+	}
+	if alg := s.Key.Algorithm(); alg != "" {
+		b.WriteString(";alg=" + quoteString(alg))
+	}
+	if s.Tag != "" {
+		b.WriteString(";tag=" + quoteString(s.Tag))
+	}
+	params := b.String()
+
+	base, err := signatureBase(components, params, func(name string) (string, error) {
+		return requestComponent(req, name)
+	})
+	if err != nil {
+		return err
+	}
+	signature, err := s.Key.Sign(base)
+	if err != nil {
+		return err
+	}
+
+	label := s.Label
+	if label == "" {
+		label = defaultSignatureLabel
+	}
+	req.SetHeader(httpheader.SignatureInput, label+"="+params)
+	req.SetHeader(httpheader.Signature, label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// MessageVerifier verifies HTTP message signatures (RFC 9421) of responses.
+type MessageVerifier struct {
+	// Keys used to verify signatures, by their key ID. If a signature has no
+	// "keyid" parameter, the only key is used.
+	Keys map[string]SignatureKey
+	// Label of the signature to verify, if empty any signature with a known
+	// key is verified.
+	Label string
+	// Components that must be covered by the signature, defaults to
+	// "@status". Supported derived components are "@status", any other
+	// component is the lower-case name of a header.
+	Components []string
+	// ContentDigest requires the signature to cover the "Content-Digest"
+	// header, and verifies the body of the response against it, see
+	// [VerifyDigests] for details.
+	ContentDigest bool
+	// MaxAge is the maximum age of a signature based on its "created"
+	// parameter, if set.
+	MaxAge time.Duration
+}
+
+// VerifyResponse verifies the HTTP message signature of res, returning a
+// [SignatureError] if there is no valid signature.
+//
+// If [MessageVerifier.ContentDigest] is set, the body of res is wrapped so
+// that reading it returns a [DigestError] once the end of the body is reached
+// if it doesn't match the digest.
+func (v *MessageVerifier) VerifyResponse(res *Response) error {
+	inputs, err := httpheader.ParseSignatureInput(strings.Join(httpheader.Values(res.Header, httpheader.SignatureInput), ", "))
+	if err != nil {
+		return SignatureError{Label: v.Label, Reason: err.Error()}
+	}
+	signatures, err := httpheader.ParseSignature(strings.Join(httpheader.Values(res.Header, httpheader.Signature), ", "))
+	if err != nil {
+		return SignatureError{Label: v.Label, Reason: err.Error()}
+	}
+
+	sErr := SignatureError{Label: v.Label, Reason: "no signature found"}
+	for _, in := range inputs {
+		if v.Label != "" && in.Label != v.Label {
+			continue
+		}
+		err := v.verify(res, in, signatures[in.Label])
+		if err == nil {
+			// Like [VerifyDigests], the body can only be verified if the
+			// response has one, and it was not already decompressed.
+			if v.ContentDigest && res.hasBody() && !res.Uncompressed {
+				return verifyDigest(res)
+			}
+			return nil
+		}
+		sErr = SignatureError{Label: in.Label, Reason: err.Error()}
+	}
+	return sErr
+}
+
+// verify verifies a single signature of res.
+func (v *MessageVerifier) verify(res *Response, in httpheader.SignatureParams, signature []byte) error {
+	if signature == nil {
+		return errors.New("missing signature")
+	}
+
+	key, ok := v.Keys[in.KeyID]
+	if !ok && in.KeyID == "" && len(v.Keys) == 1 {
+		for _, k := range v.Keys {
+			key, ok = k, true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("unknown key '%s'", in.KeyID)
+	}
+	if in.Algorithm != "" && in.Algorithm != key.Algorithm() {
+		return fmt.Errorf("unexpected algorithm '%s'", in.Algorithm)
+	}
+
+	required := v.Components
+	if len(required) == 0 {
+		required = []string{"@status"}
+	}
+	if v.ContentDigest {
+		required = append(slices.Clip(required), "content-digest")
+	}
+	for _, c := range required {
+		if !slices.Contains(in.Components, c) {
+			return fmt.Errorf("signature does not cover '%s'", c)
+		}
+	}
+
+	now := time.Now()
+	if !in.Expires.IsZero() && now.After(in.Expires) {
+		return errors.New("signature has expired")
+	}
+	if v.MaxAge > 0 && (in.Created.IsZero() || now.Sub(in.Created) > v.MaxAge) {
+		return errors.New("signature is too old")
+	}
+
+	base, err := signatureBase(in.Components, in.Params, func(name string) (string, error) {
+		return responseComponent(res, name)
+	})
+	if err != nil {
+		return err
+	}
+	return key.Verify(base, signature)
+}
+
+// formatComponents formats components as an inner list.
+func formatComponents(components []string) string {
+	quoted := make([]string, len(components))
+	for i, c := range components {
+		quoted[i] = quoteString(c)
+	}
+	return "(" + strings.Join(quoted, " ") + ")"
+}
+
+// signatureBase returns the signature base (RFC 9421, Section 2.5) of the
+// given components, using value to get the value of each component.
+func signatureBase(components []string, params string, value func(string) (string, error)) ([]byte, error) {
+	var b strings.Builder
+	for i, name := range components {
+		if name != strings.ToLower(name) || slices.Contains(components[:i], name) {
+			return nil, fmt.Errorf("nxhttp: invalid signature component '%s'", name)
+		}
+		v, err := value(name)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(quoteString(name) + ": " + v + "\n")
+	}
+	b.WriteString(`"@signature-params": ` + params)
+	return []byte(b.String()), nil
+}
+
+// requestComponent returns the value of a component of req.
+func requestComponent(req *Request, name string) (string, error) {
+	u := req.URL
+	switch name {
+	case "@method":
+		return req.Method, nil
+	case "@target-uri":
+		return u.String(), nil
+	case "@authority":
+		host := req.Host
+		if host == "" {
+			host = u.Host
+		}
+		host = strings.ToLower(host)
+		switch {
+		case u.Scheme == "http" && strings.HasSuffix(host, ":80"):
+			host = strings.TrimSuffix(host, ":80")
+		case u.Scheme == "https" && strings.HasSuffix(host, ":443"):
+			host = strings.TrimSuffix(host, ":443")
+		}
+		return host, nil
+	case "@scheme":
+		return strings.ToLower(u.Scheme), nil
+	case "@request-target":
+		return u.RequestURI(), nil
+	case "@path":
+		if p := u.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + u.RawQuery, nil
+	}
+	return headerComponent(req.Header, name)
+}
+
+// responseComponent returns the value of a component of res.
+func responseComponent(res *Response, name string) (string, error) {
+	if name == "@status" {
+		return strconv.Itoa(res.StatusCode), nil
+	}
+	return headerComponent(res.Header, name)
+}
+
+// headerComponent returns the value of a header component.
+func headerComponent(h http.Header, name string) (string, error) {
+	if strings.HasPrefix(name, "@") {
+		return "", fmt.Errorf("nxhttp: unsupported signature component '%s'", name)
+	}
+	values := httpheader.Values(h, httpheader.Canonicalize(name))
+	if len(values) == 0 {
+		return "", fmt.Errorf("nxhttp: missing header for signature component '%s'", name)
+	}
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
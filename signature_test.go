@@ -0,0 +1,416 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+func TestParseSignatureInput(t *testing.T) {
+	inputs, err := httpheader.ParseSignatureInput(`sig1=("@method" "@target-uri" "content-digest");created=1618884473;keyid="test-key, \"a\"";alg="ed25519", sig2=();nonce="a;b";tag="x"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 {
+		t.Fatalf("expected 2 signatures, but got %d", len(inputs))
+	}
+	in := inputs[0]
+	if in.Label != "sig1" || strings.Join(in.Components, " ") != "@method @target-uri content-digest" ||
+		in.Created.Unix() != 1618884473 || in.KeyID != `test-key, "a"` || in.Algorithm != "ed25519" {
+		t.Errorf("unexpected signature input %+v", in)
+	}
+	if in.Params != `("@method" "@target-uri" "content-digest");created=1618884473;keyid="test-key, \"a\"";alg="ed25519"` {
+		t.Errorf("unexpected signature params %q", in.Params)
+	}
+	if inputs[1].Label != "sig2" || len(inputs[1].Components) != 0 || inputs[1].Nonce != "a;b" || inputs[1].Tag != "x" {
+		t.Errorf("unexpected signature input %+v", inputs[1])
+	}
+
+	for _, v := range []string{"sig1", `Sig1=()`, `sig1="@method"`, `sig1=("@method";req)`, `sig1=("@method");created=abc`} {
+		if _, err := httpheader.ParseSignatureInput(v); err == nil {
+			t.Errorf("expected an error parsing '%s'", v)
+		}
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	signatures, err := httpheader.ParseSignature(`sig1=:AQI=:, *sig-2.b=:AwQ=:;x="y"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signatures) != 2 || string(signatures["sig1"]) != "\x01\x02" || string(signatures["*sig-2.b"]) != "\x03\x04" {
+		t.Errorf("unexpected signatures %v", signatures)
+	}
+
+	for _, v := range []string{"sig1", `Sig1=:AQI=:`, `1sig=:AQI=:`, `sig1=AQI=`, `sig1="AQI="`, `sig1=:!!:`} {
+		if _, err := httpheader.ParseSignature(v); err == nil {
+			t.Errorf("expected an error parsing '%s'", v)
+		}
+	}
+}
+
+// mustDecodeBase64 decodes a base64 string.
+func mustDecodeBase64(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestSignatureKey_RFC9421(t *testing.T) {
+	// The ed25519 key and shared secret from RFC 9421, Appendix B.1.
+	edKey, err := x509.ParsePKCS8PrivateKey(mustDecodeBase64(t, "MC4CAQAwBQYDK2VwBCIEIJ+DYvh6SEqVTm50DFtMDoQikTmiCqirVv9mWG9qfSnF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := mustDecodeBase64(t, "uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ==")
+
+	// The signatures from RFC 9421, Appendix B.2.5 and B.2.6, both of which
+	// are deterministic.
+	for _, tc := range []struct {
+		name      string
+		key       nxhttp.SignatureKey
+		input     string
+		signature string
+		base      string
+	}{
+		{
+			name:      "hmac-sha256",
+			key:       nxhttp.HMACKey(secret),
+			input:     `sig-b25=("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`,
+			signature: `sig-b25=:pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=:`,
+			base: `"date": Tue, 20 Apr 2021 02:07:55 GMT` + "\n" +
+				`"@authority": example.com` + "\n" +
+				`"content-type": application/json` + "\n",
+		},
+		{
+			name:      "ed25519",
+			key:       nxhttp.Ed25519Key(edKey.(ed25519.PrivateKey)),
+			input:     `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`,
+			signature: `sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:`,
+			base: `"date": Tue, 20 Apr 2021 02:07:55 GMT` + "\n" +
+				`"@method": POST` + "\n" +
+				`"@path": /foo` + "\n" +
+				`"@authority": example.com` + "\n" +
+				`"content-type": application/json` + "\n" +
+				`"content-length": 18` + "\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inputs, err := httpheader.ParseSignatureInput(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signatures, err := httpheader.ParseSignature(tc.signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(inputs) != 1 || signatures[inputs[0].Label] == nil {
+				t.Fatalf("expected a single signature, but got %+v and %v", inputs, signatures)
+			}
+			in := inputs[0]
+			if in.Created.Unix() != 1618884473 || in.KeyID == "" || in.Algorithm != "" {
+				t.Errorf("unexpected signature input %+v", in)
+			}
+
+			base := []byte(tc.base + `"@signature-params": ` + in.Params)
+			signature, err := tc.key.Sign(base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(signature, signatures[in.Label]) {
+				t.Errorf("expected signature %s, but got %s", tc.signature, base64.StdEncoding.EncodeToString(signature))
+			}
+			if err := tc.key.Verify(base, signatures[in.Label]); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// signatureKeys returns a key pair for every supported algorithm.
+func signatureKeys(t *testing.T) map[string][2]nxhttp.SignatureKey {
+	t.Helper()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacKey := nxhttp.HMACKey([]byte("secret"))
+	return map[string][2]nxhttp.SignatureKey{
+		"ed25519":           {nxhttp.Ed25519Key(edKey), nxhttp.Ed25519PublicKey(edKey.Public().(ed25519.PublicKey))},
+		"ecdsa-p256-sha256": {nxhttp.ECDSAKey(p256), nxhttp.ECDSAPublicKey(&p256.PublicKey)},
+		"ecdsa-p384-sha384": {nxhttp.ECDSAKey(p384), nxhttp.ECDSAPublicKey(&p384.PublicKey)},
+		"rsa-pss-sha512":    {nxhttp.RSAPSSKey(rsaKey), nxhttp.RSAPSSPublicKey(&rsaKey.PublicKey)},
+		"hmac-sha256":       {hmacKey, hmacKey},
+	}
+}
+
+// requestSignatureBase rebuilds the signature base of a request signed using
+// the components "@method", "@target-uri", "@authority" and "x-test".
+func requestSignatureBase(r *http.Request, params string) []byte {
+	return []byte(`"@method": ` + r.Method + "\n" +
+		`"@target-uri": http://` + r.Host + r.URL.RequestURI() + "\n" +
+		`"@authority": ` + r.Host + "\n" +
+		`"x-test": ` + strings.Join(r.Header.Values("X-Test"), ", ") + "\n" +
+		`"@signature-params": ` + params)
+}
+
+func TestMessageSigner(t *testing.T) {
+	for alg, keys := range signatureKeys(t) {
+		t.Run(alg, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				inputs, err := httpheader.ParseSignatureInput(r.Header.Get("Signature-Input"))
+				if err != nil || len(inputs) != 1 {
+					http.Error(w, "bad signature input", http.StatusBadRequest)
+					return
+				}
+				in := inputs[0]
+				if in.Label != "sig1" || in.KeyID != "test-key" || in.Algorithm != alg || time.Since(in.Created) > time.Minute {
+					http.Error(w, "unexpected signature params", http.StatusBadRequest)
+					return
+				}
+				signatures, err := httpheader.ParseSignature(r.Header.Get("Signature"))
+				if err != nil {
+					http.Error(w, "bad signature", http.StatusBadRequest)
+					return
+				}
+				if err := keys[1].Verify(requestSignatureBase(r, in.Params), signatures["sig1"]); err != nil {
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+				}
+			}))
+			defer ts.Close()
+
+			signer := &nxhttp.MessageSigner{
+				Key:        keys[0],
+				KeyID:      "test-key",
+				Components: append(nxhttp.DefaultSignatureComponents(), "x-test"),
+			}
+			c := nxhttp.FromClient(ts.Client(), nxhttp.WithSigner(signer))
+			req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL+"/path?a=b", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("X-Test", " one ")
+			req.Header.Add("X-Test", "two")
+			res, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Close()
+			if req.Header.Get("Signature") != "" {
+				t.Error("expected the caller's request to be left unsigned")
+			}
+
+			// Requests without a covered header can't be signed.
+			req, err = nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.Do(req); err == nil {
+				t.Error("expected an error signing a request without a covered header")
+			}
+		})
+	}
+}
+
+func TestMessageSigner_ContentDigest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("Content-Digest")+"\n"+r.Header.Get("Signature-Input"))
+	}))
+	defer ts.Close()
+
+	signer := &nxhttp.MessageSigner{Key: nxhttp.HMACKey([]byte("secret")), ContentDigest: true}
+	c := nxhttp.FromClient(ts.Client())
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPost, ts.URL, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req, nxhttp.WithRequestSigner(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("hello"))
+	digest, input, _ := strings.Cut(string(b), "\n")
+	if expected := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"; digest != expected {
+		t.Errorf("expected Content-Digest %q, but got %q", expected, digest)
+	}
+	if !strings.HasPrefix(input, `sig1=("@method" "@target-uri" "@authority" "content-digest");created=`) {
+		t.Errorf("unexpected Signature-Input %q", input)
+	}
+}
+
+// countingSigner is a [nxhttp.RequestSigner] that counts the requests it signs.
+type countingSigner struct {
+	n      atomic.Int32
+	signed atomic.Bool
+}
+
+func (s *countingSigner) SignRequest(_ context.Context, req *nxhttp.Request) error {
+	if req.Header.Get("Signature") != "" {
+		s.signed.Store(true)
+	}
+	req.Header.Set("Signature", "sig1=:"+base64.StdEncoding.EncodeToString([]byte{byte(s.n.Add(1))})+":")
+	return nil
+}
+
+func TestMessageSigner_Retry(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		signatures []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		signatures = append(signatures, r.Header.Get("Signature"))
+		if len(signatures) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	signer := &countingSigner{}
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()), nxhttp.WithSigner(signer))
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(signatures) != 3 || signatures[0] == signatures[1] || signatures[1] == signatures[2] {
+		t.Errorf("expected every attempt to be signed separately, but got %v", signatures)
+	}
+	if signer.signed.Load() {
+		t.Error("expected every attempt to be signed from an unsigned request")
+	}
+}
+
+func TestMessageVerifier(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey := nxhttp.Ed25519Key(key)
+
+	const body = "hello, world"
+	sum := sha256.Sum256([]byte(body))
+	contentDigest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+
+	var tamper atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		params := `("@status" "content-digest");created=` + strconv.FormatInt(time.Now().Unix(), 10) + `;keyid="server";alg="ed25519"`
+		signature, err := signKey.Sign([]byte(`"@status": 200` + "\n" + `"content-digest": ` + contentDigest + "\n" + `"@signature-params": ` + params))
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Digest", contentDigest)
+		w.Header().Set("Signature-Input", "sig1="+params)
+		w.Header().Set("Signature", "sig1=:"+base64.StdEncoding.EncodeToString(signature)+":")
+		switch tamper.Load() {
+		case 1:
+			w.Header().Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(make([]byte, 32))+":")
+		case 2:
+			_, _ = io.WriteString(w, "tampered")
+			return
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	defer ts.Close()
+
+	verifier := &nxhttp.MessageVerifier{
+		Keys:          map[string]nxhttp.SignatureKey{"server": nxhttp.Ed25519PublicKey(key.Public().(ed25519.PublicKey))},
+		ContentDigest: true,
+		MaxAge:        time.Minute,
+	}
+	c := nxhttp.FromClient(ts.Client(), nxhttp.MaxAttempts(1))
+	do := func(method string) (string, error) {
+		req, err := nxhttp.NewRequest(context.Background(), method, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Do(req, nxhttp.VerifyResponseSignature(verifier))
+		if err != nil {
+			return "", err
+		}
+		defer res.Close()
+		b, err := io.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	if b, err := do(http.MethodGet); err != nil || b != body {
+		t.Fatalf("expected %q, but got %q (%v)", body, b, err)
+	}
+
+	// The digest describes the body of a GET response, so it can't be used to
+	// verify the empty body of a HEAD response.
+	if b, err := do(http.MethodHead); err != nil || b != "" {
+		t.Errorf("expected an empty body, but got %q (%v)", b, err)
+	}
+
+	tamper.Store(1)
+	var sErr nxhttp.SignatureError
+	if _, err := do(http.MethodGet); !errors.As(err, &sErr) || sErr.Label != "sig1" {
+		t.Errorf("expected a SignatureError for a modified header, but got %v", err)
+	}
+
+	tamper.Store(2)
+	var dErr nxhttp.DigestError
+	if _, err := do(http.MethodGet); !errors.As(err, &dErr) {
+		t.Errorf("expected a DigestError for a modified body, but got %v", err)
+	}
+
+	tamper.Store(0)
+	verifier.Components = []string{"@status", "x-missing"}
+	if _, err := do(http.MethodGet); !errors.As(err, &sErr) {
+		t.Errorf("expected a SignatureError for a missing component, but got %v", err)
+	}
+}
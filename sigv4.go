@@ -0,0 +1,487 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+)
+
+const (
+	// sigV4Algorithm is the algorithm used by AWS Signature Version 4.
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	// sigV4ChunkAlgorithm is the algorithm used to sign the chunks of a
+	// streaming payload.
+	sigV4ChunkAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+	// sigV4UnsignedPayload is the payload hash of an unsigned payload.
+	sigV4UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// sigV4StreamingPayload is the payload hash of a streaming payload.
+	sigV4StreamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	// sigV4EmptyHash is the hex-encoded SHA-256 hash of an empty payload.
+	sigV4EmptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// sigV4TimeFormat is the format of the "X-Amz-Date" header.
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+const (
+	// defaultSigV4LargePayloadSize is the size above which a payload is
+	// considered large if [SigV4Signer.LargePayloadSize] is not set.
+	defaultSigV4LargePayloadSize = 1 << 20 // 1 MiB
+	// defaultSigV4ChunkSize is the size of the chunks of a streaming payload
+	// if [SigV4Signer.ChunkSize] is not set.
+	defaultSigV4ChunkSize = 64 << 10 // 64 KiB
+	// minSigV4ChunkSize is the minimum size of the chunks of a streaming
+	// payload, except for the last one.
+	minSigV4ChunkSize = 8 << 10 // 8 KiB
+)
+
+// AWSCredentials are the credentials used by a [SigV4Signer].
+//
+// AWSCredentials implements [AWSCredentialsProvider], returning itself.
+type AWSCredentials struct {
+	// AccessKeyID of the credentials.
+	AccessKeyID string
+	// SecretAccessKey of the credentials.
+	SecretAccessKey string
+	// SessionToken of temporary credentials, if set it is sent using the
+	// "X-Amz-Security-Token" header.
+	SessionToken string
+}
+
+// Ensure that [AWSCredentials] implements the [AWSCredentialsProvider]
+// interface.
+var _ AWSCredentialsProvider = AWSCredentials{}
+
+// Retrieve returns c.
+func (c AWSCredentials) Retrieve(context.Context) (AWSCredentials, error) {
+	return c, nil
+}
+
+// AWSCredentialsProvider returns the credentials used by a [SigV4Signer].
+//
+// Retrieve is called every time a request is signed, implementations should
+// cache the credentials if retrieving them is expensive.
+type AWSCredentialsProvider interface {
+	// Retrieve returns the current credentials.
+	Retrieve(ctx context.Context) (AWSCredentials, error)
+}
+
+// AWSCredentialsProviderFunc type is an adapter to allow the use of ordinary
+// functions as an [AWSCredentialsProvider]. If f is a function with the
+// appropriate signature, `AWSCredentialsProviderFunc(f)` is an
+// [AWSCredentialsProvider] that calls f.
+type AWSCredentialsProviderFunc func(ctx context.Context) (AWSCredentials, error)
+
+// Ensure that [AWSCredentialsProviderFunc] implements the
+// [AWSCredentialsProvider] interface.
+var _ AWSCredentialsProvider = (*AWSCredentialsProviderFunc)(nil)
+
+// Retrieve calls f(ctx).
+func (f AWSCredentialsProviderFunc) Retrieve(ctx context.Context) (AWSCredentials, error) {
+	return f(ctx)
+}
+
+// SigV4Payload is how the payload (body) of a request is signed by a
+// [SigV4Signer].
+type SigV4Payload int
+
+const (
+	// SigV4SignedPayload signs the SHA-256 hash of the payload, which is
+	// calculated by reading the entire body before the request is sent.
+	SigV4SignedPayload SigV4Payload = iota
+	// SigV4UnsignedPayload doesn't sign the payload, using "UNSIGNED-PAYLOAD"
+	// as its hash.
+	SigV4UnsignedPayload
+	// SigV4StreamingPayload signs the payload as it is sent using the
+	// "aws-chunked" content encoding, where every chunk of the body carries a
+	// signature that is chained to the signature of the request. This is
+	// supported by S3 (and most S3-compatible stores) for payloads of a known
+	// size.
+	SigV4StreamingPayload
+)
+
+// SigV4Signer is a [RequestSigner] that signs requests using
+// [AWS Signature Version 4], setting the "Authorization", "X-Amz-Date" and
+// (if required) "X-Amz-Content-Sha256" and "X-Amz-Security-Token" headers.
+//
+// The "Host", "Content-Type", "Content-MD5" and "Content-Encoding" headers are
+// signed along with any "X-Amz-*" headers. As requests are signed before every
+// attempt, the "X-Amz-Date" header is always current even when a request is
+// retried, staying within the clock skew allowed by the server.
+//
+// If a request already has an "X-Amz-Content-Sha256" header, its value is
+// used as the payload hash instead of reading the body.
+//
+// SigV4Signer sets the "Authorization" header, so it should not be combined
+// with an [Authenticator].
+//
+// [AWS Signature Version 4]: https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv.html
+type SigV4Signer struct {
+	// Credentials used to sign requests.
+	Credentials AWSCredentialsProvider
+	// Region requests are signed for, such as "us-east-1".
+	Region string
+	// Service requests are signed for, such as "s3".
+	Service string
+
+	// LargePayload is how the payload of requests with a body larger than
+	// LargePayloadSize (or of an unknown size) is signed, smaller payloads are
+	// always signed using [SigV4SignedPayload]. Defaults to
+	// [SigV4SignedPayload].
+	//
+	// As [SigV4StreamingPayload] requires the size of the payload to be
+	// known, bodies of an unknown size use [SigV4UnsignedPayload] instead.
+	LargePayload SigV4Payload
+	// LargePayloadSize is the size above which a payload is considered large,
+	// defaults to 1 MiB.
+	LargePayloadSize int64
+	// ChunkSize is the size of the chunks of a streaming payload, defaults to
+	// 64 KiB. The minimum size is 8 KiB.
+	ChunkSize int
+}
+
+// Ensure that [SigV4Signer] implements the [RequestSigner] interface.
+var _ RequestSigner = (*SigV4Signer)(nil)
+
+// SignRequest signs req.
+func (s *SigV4Signer) SignRequest(ctx context.Context, req *Request) error {
+	creds, err := s.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("nxhttp: failed to retrieve AWS credentials: %w", err)
+	}
+
+	now := time.Now().UTC()
+	sig := sigV4Signing{
+		key:   sigV4Key(creds.SecretAccessKey, now, s.Region, s.Service),
+		date:  now.Format(sigV4TimeFormat),
+		scope: now.Format("20060102") + "/" + s.Region + "/" + s.Service + "/aws4_request",
+	}
+
+	payload, err := s.payloadHash(req)
+	if err != nil {
+		return err
+	}
+	streaming := payload == sigV4StreamingPayload
+	chunkSize := s.ChunkSize
+	if streaming {
+		if chunkSize == 0 {
+			chunkSize = defaultSigV4ChunkSize
+		}
+		if chunkSize < minSigV4ChunkSize {
+			return fmt.Errorf("nxhttp: SigV4 chunk size must be at least %d bytes", minSigV4ChunkSize)
+		}
+		req.SetHeader(httpheader.XAmzDecodedContentLength, strconv.FormatInt(req.ContentLength, 10))
+		enc := "aws-chunked"
+		if v := httpheader.Get(req.Header, httpheader.ContentEncoding); v != "" {
+			enc += "," + v
+		}
+		req.SetHeader(httpheader.ContentEncoding, enc)
+	}
+	if payload != "" {
+		req.SetHeader(httpheader.XAmzContentSHA256, payload)
+	} else if payload, err = s.hashPayload(req); err != nil {
+		return err
+	}
+
+	req.SetHeader(httpheader.XAmzDate, sig.date)
+	if creds.SessionToken != "" {
+		req.SetHeader(httpheader.XAmzSecurityToken, creds.SessionToken)
+	}
+
+	canonicalHeaders, signedHeaders := sigV4Headers(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4Path(req.URL, s.Service != "s3"),
+		sigV4Query(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payload,
+	}, "\n")
+	signature := sig.sign(sigV4Algorithm, sigV4Hash([]byte(canonicalRequest)))
+	req.SetHeader(
+		httpheader.Authorization,
+		sigV4Algorithm+" Credential="+creds.AccessKeyID+"/"+sig.scope+", SignedHeaders="+signedHeaders+", Signature="+signature,
+	)
+
+	// The body is replaced once the request is signed, as the signatures of
+	// the chunks are chained to the signature of the request.
+	if streaming {
+		req.setBodyFunc(sig.streamingBody(req.body, req.ContentLength, chunkSize, signature), sigV4StreamingLength(req.ContentLength, chunkSize))
+	}
+	return nil
+}
+
+// payloadHash returns the payload hash of req if it doesn't need to be
+// calculated from the body.
+func (s *SigV4Signer) payloadHash(req *Request) (string, error) {
+	if v := httpheader.Get(req.Header, httpheader.XAmzContentSHA256); v != "" {
+		return v, nil
+	}
+	if req.body == nil {
+		return "", nil
+	}
+
+	size := s.LargePayloadSize
+	if size == 0 {
+		size = defaultSigV4LargePayloadSize
+	}
+	if req.ContentLength >= 0 && req.ContentLength <= size {
+		return "", nil
+	}
+	switch s.LargePayload {
+	case SigV4SignedPayload:
+		return "", nil
+	case SigV4UnsignedPayload:
+		return sigV4UnsignedPayload, nil
+	case SigV4StreamingPayload:
+		if req.ContentLength < 0 {
+			return sigV4UnsignedPayload, nil
+		}
+		return sigV4StreamingPayload, nil
+	default:
+		return "", fmt.Errorf("nxhttp: unknown SigV4 payload signing %d", s.LargePayload)
+	}
+}
+
+// hashPayload calculates the payload hash of req by reading its body, only
+// setting the "X-Amz-Content-Sha256" header if it is required.
+func (s *SigV4Signer) hashPayload(req *Request) (string, error) {
+	payload := sigV4EmptyHash
+	if req.body != nil {
+		body, err := req.body()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, body); err != nil {
+			return "", fmt.Errorf("nxhttp: failed to calculate hash of body: %w", err)
+		}
+		payload = hex.EncodeToString(h.Sum(nil))
+	}
+	// S3 requires the header on every request, other services only when the
+	// payload is not signed.
+	if s.Service == "s3" {
+		req.SetHeader(httpheader.XAmzContentSHA256, payload)
+	}
+	return payload, nil
+}
+
+// sigV4Signing are the values used to sign a single request.
+type sigV4Signing struct {
+	key   []byte
+	date  string
+	scope string
+}
+
+// sign returns the signature of a string to sign using the given algorithm
+// and hash.
+func (s sigV4Signing) sign(algorithm string, hash ...string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(algorithm + "\n" + s.date + "\n" + s.scope + "\n" + strings.Join(hash, "\n")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// streamingBody returns a [BodyFunc] that encodes the body of size n opened by
+// fn using the "aws-chunked" encoding, chaining the signature of the first
+// chunk to the seed signature of the request.
+func (s sigV4Signing) streamingBody(fn BodyFunc, n int64, chunkSize int, seed string) BodyFunc {
+	return func() (io.ReadCloser, error) {
+		body, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return &sigV4ChunkReader{
+			ReadCloser: body,
+			sig:        s,
+			prev:       seed,
+			buf:        make([]byte, chunkSize),
+			remaining:  n,
+		}, nil
+	}
+}
+
+// sigV4ChunkReader encodes a body using the "aws-chunked" encoding.
+type sigV4ChunkReader struct {
+	io.ReadCloser
+
+	sig sigV4Signing
+	// prev is the signature of the previous chunk.
+	prev string
+	// buf holds the data of a chunk.
+	buf []byte
+	// out holds the encoded chunk that is being read.
+	out bytes.Buffer
+	// remaining is the number of bytes remaining in the body.
+	remaining int64
+	done      bool
+}
+
+func (r *sigV4ChunkReader) Read(p []byte) (int, error) {
+	for r.out.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	return r.out.Read(p)
+}
+
+// next encodes the next chunk of the body, the last chunk is always empty.
+func (r *sigV4ChunkReader) next() error {
+	data := r.buf[:min(int64(len(r.buf)), r.remaining)]
+	if len(data) > 0 {
+		if _, err := io.ReadFull(r.ReadCloser, data); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return errors.New("nxhttp: body is shorter than its ContentLength")
+			}
+			return err
+		}
+	}
+	r.remaining -= int64(len(data))
+	r.done = len(data) == 0
+
+	r.prev = r.sig.sign(sigV4ChunkAlgorithm, r.prev, sigV4EmptyHash, sigV4Hash(data))
+	r.out.WriteString(strconv.FormatInt(int64(len(data)), 16) + ";chunk-signature=" + r.prev + "\r\n")
+	r.out.Write(data)
+	r.out.WriteString("\r\n")
+	return nil
+}
+
+// sigV4StreamingLength returns the length of a body of size n encoded using
+// the "aws-chunked" encoding.
+func sigV4StreamingLength(n int64, chunkSize int) int64 {
+	chunk := func(size int64) int64 {
+		return int64(len(strconv.FormatInt(size, 16))+len(";chunk-signature=")+sha256.Size*2+4) + size
+	}
+	size := int64(chunkSize)
+	length := n/size*chunk(size) + chunk(0)
+	if rem := n % size; rem > 0 {
+		length += chunk(rem)
+	}
+	return length
+}
+
+// sigV4Key derives the signing key for the given date, region and service.
+func sigV4Key(secret string, t time.Time, region, service string) []byte {
+	key := []byte("AWS4" + secret)
+	for _, v := range []string{t.Format("20060102"), region, service, "aws4_request"} {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(v))
+		key = h.Sum(nil)
+	}
+	return key
+}
+
+// sigV4Hash returns the hex-encoded SHA-256 hash of b.
+func sigV4Hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// sigV4Path returns the canonical URI of u. Every segment of the path is
+// URI-encoded once, or twice if double is set (as required by every service
+// other than S3).
+func sigV4Path(u *url.URL, double bool) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		s = sigV4Escape(s)
+		if double {
+			s = sigV4Escape(s)
+		}
+		segments[i] = s
+	}
+	return strings.Join(segments, "/")
+}
+
+// sigV4Query returns the canonical query string of u.
+func sigV4Query(u *url.URL) string {
+	var params [][2]string
+	for k, values := range u.Query() {
+		for _, v := range values {
+			params = append(params, [2]string{sigV4Escape(k), sigV4Escape(v)})
+		}
+	}
+	slices.SortFunc(params, func(a, b [2]string) int {
+		return cmp.Or(strings.Compare(a[0], b[0]), strings.Compare(a[1], b[1]))
+	})
+
+	pairs := make([]string, len(params))
+	for i, p := range params {
+		pairs[i] = p[0] + "=" + p[1]
+	}
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Escape URI-encodes s, only leaving unreserved characters unencoded.
+func sigV4Escape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xf])
+		}
+	}
+	return b.String()
+}
+
+// sigV4Headers returns the canonical headers and signed headers of req.
+func sigV4Headers(req *Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, values := range req.Header {
+		name := strings.ToLower(k)
+		switch {
+		case name == "content-type", name == "content-md5", name == "content-encoding":
+		case strings.HasPrefix(name, "x-amz-"):
+		default:
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
@@ -0,0 +1,351 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+const (
+	testAccessKeyID     = "AKIDEXAMPLE"
+	testSecretAccessKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+func hmacSHA256(key []byte, v string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(v))
+	return h.Sum(nil)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func escapeSigV4(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// sigV4Verifier independently verifies requests signed using AWS Signature
+// Version 4.
+type sigV4Verifier struct {
+	region, service string
+}
+
+// signingKey returns the signing key for the given date.
+func (v sigV4Verifier) signingKey(date string) []byte {
+	key := hmacSHA256([]byte("AWS4"+testSecretAccessKey), date[:8])
+	key = hmacSHA256(key, v.region)
+	key = hmacSHA256(key, v.service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// signature returns the expected signature of r, which has the given signed
+// headers and payload hash.
+func (v sigV4Verifier) signature(r *http.Request, signedHeaders []string, payload string) string {
+	segments := strings.Split(r.URL.Path, "/")
+	for i, s := range segments {
+		s = escapeSigV4(s)
+		if v.service != "s3" {
+			s = escapeSigV4(s)
+		}
+		segments[i] = s
+	}
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers.WriteString(name + ":" + strings.Join(strings.Fields(value), " ") + "\n")
+	}
+
+	query := r.URL.Query()
+	for _, values := range query {
+		slices.Sort(values)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		strings.Join(segments, "/"),
+		strings.ReplaceAll(query.Encode(), "+", "%20"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payload,
+	}, "\n")
+	date := r.Header.Get("X-Amz-Date")
+	scope := date[:8] + "/" + v.region + "/" + v.service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	return hex.EncodeToString(hmacSHA256(v.signingKey(date), stringToSign))
+}
+
+// verify verifies the signature of r, returning the decoded body.
+func (v sigV4Verifier) verify(r *http.Request) ([]byte, error) {
+	date, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, err
+	}
+	if d := time.Since(date); d < -time.Minute || d > time.Minute {
+		return nil, fmt.Errorf("request date %s is not current", date)
+	}
+
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return nil, errors.New("unexpected authorization scheme")
+	}
+	params := make(map[string]string)
+	for p := range strings.SplitSeq(auth, ", ") {
+		k, v, _ := strings.Cut(p, "=")
+		params[k] = v
+	}
+	if expected := testAccessKeyID + "/" + date.Format("20060102") + "/" + v.region + "/" + v.service + "/aws4_request"; params["Credential"] != expected {
+		return nil, fmt.Errorf("expected credential %q, but got %q", expected, params["Credential"])
+	}
+
+	payload := r.Header.Get("X-Amz-Content-Sha256")
+	var body []byte
+	if payload == "" || (payload != "UNSIGNED-PAYLOAD" && !strings.HasPrefix(payload, "STREAMING-")) {
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		if payload == "" {
+			payload = sha256Hex(body)
+		} else if payload != sha256Hex(body) {
+			return nil, errors.New("payload hash mismatch")
+		}
+	}
+
+	signature := v.signature(r, strings.Split(params["SignedHeaders"], ";"), payload)
+	if signature != params["Signature"] {
+		return nil, errors.New("signature mismatch")
+	}
+
+	switch payload {
+	case "UNSIGNED-PAYLOAD":
+		return io.ReadAll(r.Body)
+	case "STREAMING-AWS4-HMAC-SHA256-PAYLOAD":
+		return v.readChunks(r, signature)
+	}
+	return body, nil
+}
+
+// readChunks decodes and verifies a body using the "aws-chunked" encoding.
+func (v sigV4Verifier) readChunks(r *http.Request, prev string) ([]byte, error) {
+	date := r.Header.Get("X-Amz-Date")
+	key := v.signingKey(date)
+	scope := date[:8] + "/" + v.region + "/" + v.service + "/aws4_request"
+
+	var body []byte
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, signature, ok := strings.Cut(strings.TrimSuffix(line, "\r\n"), ";chunk-signature=")
+		if !ok {
+			return nil, fmt.Errorf("malformed chunk header %q", line)
+		}
+		n, err := strconv.ParseInt(size, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, err
+		}
+		data = data[:n]
+
+		stringToSign := "AWS4-HMAC-SHA256-PAYLOAD\n" + date + "\n" + scope + "\n" + prev + "\n" + sha256Hex(nil) + "\n" + sha256Hex(data)
+		if prev = hex.EncodeToString(hmacSHA256(key, stringToSign)); prev != signature {
+			return nil, errors.New("chunk signature mismatch")
+		}
+		body = append(body, data...)
+		if n == 0 {
+			return body, nil
+		}
+	}
+}
+
+func (v sigV4Verifier) handler(t *testing.T, fn func(r *http.Request, body []byte)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := v.verify(r)
+		if err != nil {
+			t.Errorf("failed to verify request: %v", err)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if fn != nil {
+			fn(r, body)
+		}
+	})
+}
+
+func TestSigV4Verifier(t *testing.T) {
+	// "get-vanilla" from the AWS Signature Version 4 test suite, which
+	// ensures the verifier used by the other tests is correct.
+	r := httptest.NewRequest(http.MethodGet, "http://example.amazonaws.com/", nil)
+	r.Header.Set("X-Amz-Date", "20150830T123600Z")
+	signature := sigV4Verifier{region: "us-east-1", service: "service"}.signature(r, []string{"host", "x-amz-date"}, sha256Hex(nil))
+	if expected := "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"; signature != expected {
+		t.Fatalf("expected signature %s, but got %s", expected, signature)
+	}
+}
+
+func newSigV4Signer(service string) *nxhttp.SigV4Signer {
+	return &nxhttp.SigV4Signer{
+		Credentials: nxhttp.AWSCredentials{AccessKeyID: testAccessKeyID, SecretAccessKey: testSecretAccessKey},
+		Region:      "us-east-1",
+		Service:     service,
+	}
+}
+
+func TestSigV4Signer(t *testing.T) {
+	for _, service := range []string{"s3", "execute-api"} {
+		t.Run(service, func(t *testing.T) {
+			v := sigV4Verifier{region: "us-east-1", service: service}
+			var got atomic.Value
+			ts := httptest.NewServer(v.handler(t, func(r *http.Request, body []byte) {
+				got.Store(r.Header.Get("X-Amz-Security-Token") + " " + string(body))
+			}))
+			defer ts.Close()
+
+			signer := newSigV4Signer(service)
+			signer.Credentials = nxhttp.AWSCredentialsProviderFunc(func(context.Context) (nxhttp.AWSCredentials, error) {
+				return nxhttp.AWSCredentials{AccessKeyID: testAccessKeyID, SecretAccessKey: testSecretAccessKey, SessionToken: "token"}, nil
+			})
+			c := nxhttp.FromClient(ts.Client(), nxhttp.WithSigner(signer))
+			req, err := nxhttp.NewRequest(context.Background(), http.MethodPut, ts.URL+"/bucket/a%20b/c+d?z=1&a=x%20y&a=b&empty=", "hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "text/plain")
+			req.Header.Set("X-Amz-Meta-Test", "  a   b ")
+			res, err := c.Do(req, nxhttp.ExpectStatus(http.StatusOK))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Close()
+
+			if v := got.Load(); v != "token hello" {
+				t.Errorf("expected the server to receive %q, but got %q", "token hello", v)
+			}
+			if req.Header.Get("Authorization") != "" {
+				t.Error("expected the caller's request to be left unsigned")
+			}
+		})
+	}
+}
+
+func TestSigV4Signer_Retry(t *testing.T) {
+	var n atomic.Int32
+	v := sigV4Verifier{region: "us-east-1", service: "s3"}
+	handler := v.handler(t, func(_ *http.Request, body []byte) {
+		if string(body) != "hello" {
+			t.Errorf("expected body %q, but got %q", "hello", body)
+		}
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every attempt must carry a valid signature.
+		handler.ServeHTTP(w, r)
+		if n.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	c := nxhttp.FromClient(
+		ts.Client(),
+		nxhttp.WithBackoff(fastBackoff()),
+		nxhttp.WithSigner(newSigV4Signer("s3")),
+	)
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodPut, ts.URL+"/bucket/key", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Do(req, nxhttp.RetryNonIdempotent(), nxhttp.ExpectStatus(http.StatusOK))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Close()
+
+	if n.Load() != 3 {
+		t.Errorf("expected 3 attempts, but got %d", n.Load())
+	}
+}
+
+func TestSigV4Signer_LargePayload(t *testing.T) {
+	body := strings.Repeat("0123456789", 2500)
+	for _, tc := range []struct {
+		name    string
+		payload nxhttp.SigV4Payload
+		body    any
+		hash    string
+	}{
+		{"signed", nxhttp.SigV4SignedPayload, body, sha256Hex([]byte(body))},
+		{"unsigned", nxhttp.SigV4UnsignedPayload, body, "UNSIGNED-PAYLOAD"},
+		{"streaming", nxhttp.SigV4StreamingPayload, body, "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"},
+		{
+			"streaming unknown size",
+			nxhttp.SigV4StreamingPayload,
+			nxhttp.ReadOpenerFor(func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(body)), nil }, -1),
+			"UNSIGNED-PAYLOAD",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := sigV4Verifier{region: "us-east-1", service: "s3"}
+			ts := httptest.NewServer(v.handler(t, func(r *http.Request, b []byte) {
+				if h := r.Header.Get("X-Amz-Content-Sha256"); h != tc.hash {
+					t.Errorf("expected payload hash %q, but got %q", tc.hash, h)
+				}
+				if string(b) != body {
+					t.Errorf("expected a body of %d bytes, but got %d bytes", len(body), len(b))
+				}
+				if tc.hash == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+					if d := r.Header.Get("X-Amz-Decoded-Content-Length"); d != strconv.Itoa(len(body)) {
+						t.Errorf("expected a decoded content length of %d, but got %s", len(body), d)
+					}
+					if e := r.Header.Get("Content-Encoding"); e != "aws-chunked" {
+						t.Errorf("expected the aws-chunked content encoding, but got %q", e)
+					}
+				}
+			}))
+			defer ts.Close()
+
+			signer := newSigV4Signer("s3")
+			signer.LargePayload = tc.payload
+			signer.LargePayloadSize = 1024
+			signer.ChunkSize = 8 << 10
+			c := nxhttp.FromClient(ts.Client(), nxhttp.WithSigner(signer), nxhttp.MaxAttempts(1))
+			req, err := nxhttp.NewRequest(context.Background(), http.MethodPut, ts.URL+"/bucket/key", tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := c.Do(req, nxhttp.ExpectStatus(http.StatusOK))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Close()
+		})
+	}
+}
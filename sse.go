@@ -0,0 +1,388 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matthewpi/nxhttp/httpheader"
+	"github.com/matthewpi/nxretry"
+)
+
+// contentTypeEventStream is the media type used for Server-Sent Events.
+const contentTypeEventStream = "text/event-stream"
+
+// defaultEventRetry is the delay before reconnecting to an event stream that
+// ended after receiving events, unless the server requested a different
+// reconnection time.
+const defaultEventRetry = 3 * time.Second
+
+// maxEventLineSize is the maximum size of a single line in an event stream.
+const maxEventLineSize = 1024 * 1024
+
+// Event is a single [Server-Sent Event].
+//
+// [Server-Sent Event]: https://html.spec.whatwg.org/multipage/server-sent-events.html
+type Event struct {
+	// ID of the event, or the ID of the last event that set one.
+	ID string
+
+	// Type of the event. If the server didn't specify one, the type is
+	// "message".
+	Type string
+
+	// Data of the event. Multiple "data" fields are joined with a newline.
+	Data string
+
+	// Retry is the reconnection time requested by the server, or zero if the
+	// server has not requested one.
+	Retry time.Duration
+}
+
+// ReadEvents returns an iterator over the [Server-Sent Events] in the body of
+// res.
+//
+// If an error occurs while reading the body, it is yielded and the iteration
+// stops. The response is closed once the iteration stops, including when the
+// caller breaks out of the loop early, in which case the rest of the stream is
+// not read. The iterator is single-use.
+//
+// To automatically reconnect when the stream ends, use [Client.Subscribe].
+//
+// [Server-Sent Events]: https://html.spec.whatwg.org/multipage/server-sent-events.html
+func ReadEvents(res *Response) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		// An event stream may never end, so close the response without
+		// reading the rest of the stream.
+		defer res.abort()
+		newEventReader(responseBody(res), "").read(yield)
+	}
+}
+
+// Subscribe sends req using c and returns an iterator over the
+// [Server-Sent Events] received in response.
+//
+// Whenever the event stream ends or fails, Subscribe reconnects using the
+// configured [nxretry.Backoff], sending the ID of the last event received in
+// the "Last-Event-ID" header. If the server requested a reconnection time
+// using the "retry" field, it is used as the delay before reconnecting
+// instead. The backoff is reset whenever a connection receives an event, in
+// which case the delay before reconnecting is the server's reconnection time,
+// or 3 seconds if it has not requested one.
+//
+// The iteration stops when the consumer breaks out of the loop, the request's
+// context is done, the server responds with a "204 No Content" status code,
+// or after the maximum number of consecutive attempts that did not receive
+// an event. Any error that stops the iteration is yielded first.
+//
+// NOTE: a client configured using [WithTimeout] will interrupt long-lived
+// event streams once the timeout is reached.
+//
+// [Server-Sent Events]: https://html.spec.whatwg.org/multipage/server-sent-events.html
+func (c *Client) Subscribe(req *Request, opts ...RequestOption) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		ctx := req.Context()
+		req := req.clone()
+		if httpheader.Get(req.Header, httpheader.Accept) == "" {
+			req.SetHeader(httpheader.Accept, contentTypeEventStream)
+		}
+
+		reqOpts := c.requestOptions()
+		for _, opt := range opts {
+			opt.apply(reqOpts)
+		}
+
+		// Reconnects are retried by the loop below, so every connection only
+		// makes a single attempt.
+		opts := append(slices.Clip(opts), WithRequestMaxAttempts(1))
+
+		// Resume from the ID set by the caller, if any.
+		s := &subscription{
+			client: c,
+			req:    req,
+			opts:   opts,
+			yield:  yield,
+			lastID: httpheader.Get(req.Header, httpheader.LastEventID),
+		}
+		for {
+			var (
+				err      error
+				received bool
+			)
+			rty := nxretry.New(
+				nxretry.MaxAttempts(reqOpts.maxAttempts),
+				reqOpts.backoff,
+			)
+			for range rty.Next(ctx) {
+				var stop bool
+				stop, received, err = s.connect()
+				if stop {
+					if err != nil {
+						yield(Event{}, err)
+					}
+					return
+				}
+				if received {
+					break
+				}
+				if d := retryAfter(err); d > 0 {
+					rty.Override(d)
+				} else if s.retry > 0 {
+					rty.Override(s.retry)
+				}
+			}
+
+			// Give up if we ran out of attempts without receiving an event,
+			// or if the context is done.
+			if !received {
+				if ctx.Err() == nil {
+					if err == nil {
+						err = io.ErrUnexpectedEOF
+					}
+					yield(Event{}, err)
+				}
+				return
+			}
+
+			// We received at least one event, so reset the backoff and
+			// reconnect, respecting the server's reconnection time.
+			d := s.retry
+			if d <= 0 {
+				d = defaultEventRetry
+			}
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// subscription tracks the state of an event stream across reconnects.
+type subscription struct {
+	client *Client
+	req    *Request
+	opts   []RequestOption
+	yield  func(Event, error) bool
+
+	// lastID is the ID of the last event that set one.
+	lastID string
+	// retry is the reconnection time requested by the server.
+	retry time.Duration
+}
+
+// connect connects to the event stream and yields events until it ends.
+//
+// If stop is true, the subscription must not reconnect and err (if any)
+// should be yielded. Otherwise, received indicates whether any events were
+// received before the stream ended or failed with err.
+func (s *subscription) connect() (stop, received bool, err error) {
+	// The server may reset the ID using an empty "id" field, in which case
+	// the header must not be sent anymore.
+	if s.lastID != "" {
+		s.req.SetHeader(httpheader.LastEventID, s.lastID)
+	} else {
+		s.req.DelHeader(httpheader.LastEventID)
+	}
+
+	res, err := s.client.Do(s.req, s.opts...)
+	if err != nil {
+		return false, false, err
+	}
+	closeRes := res.Close
+	defer func() { _ = closeRes() }()
+
+	// A "204 No Content" response indicates the server wants us to stop
+	// reconnecting, while any other unsuccessful response is fatal.
+	if res.StatusCode == http.StatusNoContent {
+		return true, false, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return true, false, NewStatusError(res, http.StatusOK)
+	}
+	if v := res.GetHeader(httpheader.ContentType); !isEventStream(v) {
+		return true, false, NewContentError(httpheader.ContentType, v, contentTypeEventStream)
+	}
+
+	// An event stream may never end, so close the response without reading
+	// the rest of the stream.
+	closeRes = res.abort
+
+	r := newEventReader(responseBody(res), s.lastID)
+	defer func() {
+		s.lastID = r.lastID
+		if r.retry > 0 {
+			s.retry = r.retry
+		}
+	}()
+	r.read(func(e Event, err error) bool {
+		if err != nil {
+			return false
+		}
+		received = true
+		return s.yield(e, nil)
+	})
+	if r.err == nil && !r.eof {
+		// The consumer broke out of the loop.
+		return true, received, nil
+	}
+	return false, received, r.err
+}
+
+// retryAfter returns the delay requested by the server in the response to the
+// final attempt of a failed request, if any.
+func retryAfter(err error) time.Duration {
+	var rErr RetryExhaustedError
+	if !errors.As(err, &rErr) || len(rErr.Attempts) == 0 {
+		return 0
+	}
+	return rErr.Attempts[len(rErr.Attempts)-1].RetryAfter
+}
+
+// isEventStream reports whether the media type v is "text/event-stream".
+func isEventStream(v string) bool {
+	mediaType, _, err := mime.ParseMediaType(v)
+	return err == nil && mediaType == contentTypeEventStream
+}
+
+// eventReader parses an event stream.
+type eventReader struct {
+	s *bufio.Scanner
+
+	// lastID is the ID of the last event that set one.
+	lastID string
+	// retry is the reconnection time requested by the server.
+	retry time.Duration
+
+	// err that stopped the reader, if any.
+	err error
+	// eof indicates the reader reached the end of the stream.
+	eof bool
+}
+
+// newEventReader returns a new [eventReader] for r, where lastID is the ID
+// of the last event received in a previous stream.
+func newEventReader(r io.Reader, lastID string) *eventReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, maxEventLineSize)
+	s.Split(scanEventLines)
+	return &eventReader{s: s, lastID: lastID}
+}
+
+// read parses events from the stream, calling yield for every event that is
+// dispatched, until the stream ends or yield returns false.
+//
+// See https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+func (r *eventReader) read(yield func(Event, error) bool) {
+	var (
+		eventType string
+		data      strings.Builder
+		first     = true
+	)
+	for r.s.Scan() {
+		line := r.s.Bytes()
+		if first {
+			// Strip the UTF-8 byte order mark (if any).
+			line = bytes.TrimPrefix(line, []byte("\xef\xbb\xbf"))
+			first = false
+		}
+
+		// An empty line dispatches the event.
+		if len(line) == 0 {
+			if data.Len() == 0 {
+				eventType = ""
+				continue
+			}
+			e := Event{
+				ID:    r.lastID,
+				Type:  eventType,
+				Data:  strings.TrimSuffix(data.String(), "\n"),
+				Retry: r.retry,
+			}
+			if e.Type == "" {
+				e.Type = "message"
+			}
+			eventType = ""
+			data.Reset()
+			if !yield(e, nil) {
+				return
+			}
+			continue
+		}
+
+		// Lines starting with a colon are comments.
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			eventType = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+		case "id":
+			if bytes.IndexByte(value, 0) == -1 {
+				r.lastID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 32); err == nil {
+				r.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := r.s.Err(); err != nil {
+		r.err = err
+		yield(Event{}, err)
+		return
+	}
+
+	// Any incomplete event is discarded once the stream ends.
+	r.eof = true
+}
+
+// scanEventLines is a [bufio.SplitFunc] that splits an event stream into lines,
+// which may be terminated by "\r\n", "\n", or "\r".
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+
+		// We need to know the next byte to determine if the "\r" is part of
+		// a "\r\n" line ending.
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: Copyright (c) 2026 Matthew Penner
+
+package nxhttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matthewpi/nxhttp"
+)
+
+func TestReadEvents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "\xef\xbb\xbf: comment\r\ndata: first\r\ndata:second\r\n\r\nevent: update\rid: 1\rretry: 1500\rdata: {}\r\r")
+		_, _ = io.WriteString(w, "id\ndata\n\ndata: incomplete")
+	}))
+	defer ts.Close()
+
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := nxhttp.FromClient(ts.Client()).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []nxhttp.Event
+	for e, err := range nxhttp.ReadEvents(res) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+
+	expected := []nxhttp.Event{
+		{Type: "message", Data: "first\nsecond"},
+		{ID: "1", Type: "update", Data: "{}", Retry: 1500 * time.Millisecond},
+		{Type: "message", Data: "", Retry: 1500 * time.Millisecond},
+	}
+	if !slices.Equal(events, expected) {
+		t.Errorf("expected %+v, but got %+v", expected, events)
+	}
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n.Add(1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "retry: 1\nid: a\ndata: 1\n\n")
+		case 2:
+			if id := r.Header.Get("Last-Event-ID"); id != "a" {
+				t.Errorf("expected Last-Event-ID to be %q, but got %q", "a", id)
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "id: b\ndata: 2\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data []string
+	for e, err := range nxhttp.FromClient(ts.Client()).Subscribe(req) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, e.Data)
+	}
+	if got := strings.Join(data, ","); got != "1,2" {
+		t.Errorf("expected events %q, but got %q", "1,2", got)
+	}
+}
+
+func TestClient_Subscribe_ResetID(t *testing.T) {
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n.Add(1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "retry: 1\nid: a\ndata: 1\n\n")
+		case 2:
+			// An empty "id" field resets the ID of the last event.
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "id:\ndata: 2\n\n")
+		case 3:
+			if id, ok := r.Header["Last-Event-Id"]; ok {
+				t.Errorf("expected Last-Event-ID to not be set, but got %q", id)
+			}
+			fallthrough
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range nxhttp.FromClient(ts.Client()).Subscribe(req) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := n.Load(); got != 3 {
+		t.Errorf("expected 3 requests, but got %d", got)
+	}
+}
+
+// newOpenEventStreamServer returns a server that sends a single event and then
+// keeps the stream open without sending anything else.
+func newOpenEventStreamServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "id: 1\ndata: first\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	t.Cleanup(ts.Close)
+	t.Cleanup(func() { close(done) })
+	return ts
+}
+
+// breakAfterFirst consumes the first event of events and breaks out of the
+// loop, failing the test if that doesn't return promptly.
+func breakAfterFirst(t *testing.T, events func(func(nxhttp.Event, error) bool)) {
+	t.Helper()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for e, err := range events {
+			if err != nil {
+				t.Error(err)
+			} else if e.Data != "first" {
+				t.Errorf("expected event %q, but got %q", "first", e.Data)
+			}
+			break
+		}
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("breaking out of an open event stream did not return")
+	}
+}
+
+func TestReadEvents_Break(t *testing.T) {
+	ts := newOpenEventStreamServer(t)
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := nxhttp.FromClient(ts.Client()).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	breakAfterFirst(t, nxhttp.ReadEvents(res))
+}
+
+func TestClient_Subscribe_Break(t *testing.T) {
+	ts := newOpenEventStreamServer(t)
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	breakAfterFirst(t, nxhttp.FromClient(ts.Client()).Subscribe(req))
+
+	if len(req.Header) != 0 {
+		t.Errorf("expected the caller's request to be left unchanged, but got headers %v", req.Header)
+	}
+}
+
+func TestClient_Subscribe_Attempts(t *testing.T) {
+	ts, n := newRetryTestServer(t, http.StatusServiceUnavailable)
+	req, err := nxhttp.NewRequest(context.Background(), http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := nxhttp.FromClient(ts.Client(), nxhttp.WithBackoff(fastBackoff()))
+	var errs int
+	for _, err := range c.Subscribe(req) {
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("expected a single error, but got %d", errs)
+	}
+	if got := n.Load(); got != 3 {
+		t.Errorf("expected 3 requests, but got %d", got)
+	}
+}